@@ -0,0 +1,517 @@
+// Package classifier tells the kind of SQL statements and the tables they
+// read and write, without parsing them fully. It's meant to run on every
+// call, from hooks:
+//
+//	func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+//		if stmt := classifier.Classify(query); stmt.IsWrite() {
+//			log.Printf("writing to %v", stmt.Writes)
+//		}
+//		return ctx, nil
+//	}
+package classifier
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqlscan"
+)
+
+// Dialect defines the quoting rules of statements. The zero Dialect accepts
+// the syntax of every dialect.
+type Dialect int
+
+const (
+	MySQL Dialect = iota + 1
+	Postgres
+	SQLite
+)
+
+// Kind is the kind of a statement.
+type Kind int
+
+const (
+	// Other statements are the ones not covered by the other kinds, like
+	// SET, SHOW or PRAGMA.
+	Other Kind = iota
+	Select
+	Insert
+	Update
+	Delete
+	// DDL statements define the schema: CREATE, ALTER, DROP, TRUNCATE.
+	DDL
+	// TCL statements control transactions: BEGIN, COMMIT, ROLLBACK.
+	TCL
+	// Merge statements insert, update or delete rows: MERGE.
+	Merge
+	// Call statements run procedures, whose effects are unknown: CALL,
+	// EXEC, EXECUTE and DO.
+	Call
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Select:
+		return "SELECT"
+	case Insert:
+		return "INSERT"
+	case Update:
+		return "UPDATE"
+	case Delete:
+		return "DELETE"
+	case DDL:
+		return "DDL"
+	case TCL:
+		return "TCL"
+	case Merge:
+		return "MERGE"
+	case Call:
+		return "CALL"
+	}
+	return "OTHER"
+}
+
+// Statement describes a query.
+type Statement struct {
+	// Kind is the kind of the first statement of the query.
+	Kind Kind
+	// Kinds are the kinds of every statement of the query, in order.
+	Kinds []Kind
+	// Reads and Writes are the tables read and written by the query, in
+	// the order they appear, with their schema if given. Quoted names are
+	// unquoted.
+	Reads  []string
+	Writes []string
+	// Multi is true if the query holds more than one statement.
+	Multi bool
+}
+
+// IsWrite reports whether any statement of the query writes, changes the
+// schema or calls a procedure. TCL and Other statements are not considered
+// writes.
+func (s Statement) IsWrite() bool {
+	for _, kind := range s.Kinds {
+		switch kind {
+		case Insert, Update, Delete, DDL, Merge, Call:
+			return true
+		}
+	}
+	return len(s.Writes) > 0
+}
+
+// Classifier classifies queries, caching the results of the most recent
+// ones. It's safe for concurrent use.
+type Classifier struct {
+	dialect Dialect
+	size    int
+
+	mu    sync.Mutex
+	lru   *list.List
+	cache map[string]*list.Element
+}
+
+type entry struct {
+	query string
+	stmt  Statement
+}
+
+// New returns a Classifier following the quoting rules of dialect, which
+// caches up to size queries.
+func New(dialect Dialect, size int) *Classifier {
+	return &Classifier{
+		dialect: dialect,
+		size:    size,
+		lru:     list.New(),
+		cache:   make(map[string]*list.Element),
+	}
+}
+
+var std = New(0, 1024)
+
+// Classify classifies query with a Classifier shared by the package, which
+// accepts every dialect and caches 1024 queries.
+func Classify(query string) Statement {
+	return std.Classify(query)
+}
+
+// Classify returns the Statement of query. Its slices are shared between
+// calls and must not be modified.
+func (c *Classifier) Classify(query string) Statement {
+	c.mu.Lock()
+	if e, ok := c.cache[query]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*entry).stmt
+	}
+	c.mu.Unlock()
+
+	stmt := classify(c.dialect, query)
+	if c.size <= 0 {
+		return stmt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.cache[query]; !ok {
+		c.cache[query] = c.lru.PushFront(&entry{query, stmt})
+		if c.lru.Len() > c.size {
+			oldest := c.lru.Remove(c.lru.Back()).(*entry)
+			delete(c.cache, oldest.query)
+		}
+	}
+	return stmt
+}
+
+var kinds = map[string]Kind{
+	"select": Select, "values": Select, "table": Select,
+	"insert": Insert, "replace": Insert,
+	"update": Update, "merge": Merge,
+	"delete": Delete,
+	"call":   Call, "exec": Call, "execute": Call, "do": Call,
+	"create": DDL, "alter": DDL, "drop": DDL, "truncate": DDL, "rename": DDL,
+	"begin": TCL, "start": TCL, "commit": TCL, "rollback": TCL, "end": TCL,
+	"savepoint": TCL, "release": TCL,
+}
+
+// functions take FROM as an argument, i.e: EXTRACT(YEAR FROM t).
+var functions = map[string]bool{
+	"extract": true, "substring": true, "trim": true, "position": true, "overlay": true,
+}
+
+// classifier holds the state of the classification of a query.
+type classifier struct {
+	tokens []sqlscan.Token
+	stmt   Statement
+	ctes   map[string]bool
+	seen   map[string]bool
+}
+
+func classify(dialect Dialect, query string) Statement {
+	c := &classifier{ctes: make(map[string]bool), seen: make(map[string]bool)}
+	for _, tok := range sqlscan.Scan(sqlscan.Dialect(dialect), query) {
+		if tok.Kind != sqlscan.Space && tok.Kind != sqlscan.Comment {
+			c.tokens = append(c.tokens, tok)
+		}
+	}
+
+	for _, stmt := range split(c.tokens) {
+		c.stmt.Kinds = append(c.stmt.Kinds, c.statement(stmt))
+	}
+	if len(c.stmt.Kinds) > 0 {
+		c.stmt.Kind = c.stmt.Kinds[0]
+	}
+
+	c.stmt.Multi = len(c.stmt.Kinds) > 1
+	return c.stmt
+}
+
+// split splits tokens into statements, on the semicolons outside of
+// parentheses. Empty statements are omitted.
+func split(tokens []sqlscan.Token) [][]sqlscan.Token {
+	var stmts [][]sqlscan.Token
+	start, depth := 0, 0
+	for i, tok := range tokens {
+		switch tok.Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ";":
+			if depth <= 0 {
+				if i > start {
+					stmts = append(stmts, tokens[start:i])
+				}
+				start, depth = i+1, 0
+			}
+		}
+	}
+	if start < len(tokens) {
+		stmts = append(stmts, tokens[start:])
+	}
+	return stmts
+}
+
+// statement collects the tables of a single statement and returns its kind.
+func (c *classifier) statement(tokens []sqlscan.Token) Kind {
+	kind := kinds[keyword(tokens[0])]
+	switch keyword(tokens[0]) {
+	case "with":
+		kind = c.with(tokens)
+	case "set":
+		if len(tokens) > 1 && keyword(tokens[1]) == "transaction" {
+			kind = TCL
+		}
+	case "copy":
+		if len(tokens) > 1 && tokens[1].Text != "(" {
+			return c.copy(tokens)
+		}
+		// COPY (query) TO
+		kind = Select
+	case "load":
+		// MySQL LOAD DATA and LOAD XML
+		if len(tokens) > 1 && (keyword(tokens[1]) == "data" || keyword(tokens[1]) == "xml") {
+			kind = Insert
+		}
+	}
+
+	// parens holds whether each open parenthesis belongs to a function
+	// taking FROM as an argument.
+	var parens []bool
+	inFunction := func() bool { return len(parens) > 0 && parens[len(parens)-1] }
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.Text {
+		case "(":
+			parens = append(parens, i > 0 && functions[keyword(tokens[i-1])])
+			continue
+		case ")":
+			if len(parens) > 0 {
+				parens = parens[:len(parens)-1]
+			}
+			continue
+		}
+
+		switch keyword(tok) {
+		case "from":
+			if inFunction() {
+				continue
+			}
+			if i > 0 && keyword(tokens[i-1]) == "delete" {
+				i = c.tables(tokens, i+1, false, true)
+			} else {
+				i = c.tables(tokens, i+1, true, true)
+			}
+		case "join":
+			i = c.tables(tokens, i+1, true, false)
+		case "using":
+			switch kind {
+			case Delete:
+				i = c.tables(tokens, i+1, true, true)
+			case Merge:
+				i = c.tables(tokens, i+1, true, false)
+			}
+		case "into":
+			switch {
+			case kind == Insert, kind == Merge, i > 0 && (keyword(tokens[i-1]) == "insert" || keyword(tokens[i-1]) == "merge"):
+				// LOAD DATA ... INTO TABLE
+				i = c.table(tokens, skip(tokens, i+1, "table"), false)
+			case kind == Select && len(parens) == 0:
+				// SELECT ... INTO creates a table
+				i = c.table(tokens, skip(tokens, i+1, "temp", "temporary", "unlogged", "table"), false)
+			}
+		case "update":
+			if starts(tokens, i) {
+				i = c.tables(tokens, i+1, false, true)
+			}
+		case "delete":
+			// MySQL DELETE a, b FROM a JOIN b names the tables it deletes
+			// from before FROM
+			if j := skip(tokens, i+1, "low_priority", "quick", "ignore"); starts(tokens, i) && j < len(tokens) && keyword(tokens[j]) != "from" {
+				i = c.deleted(tokens, j)
+			}
+		case "insert", "replace":
+			// MySQL allows omitting INTO
+			if j := skip(tokens, i+1, "ignore", "low_priority", "delayed", "high_priority"); j < len(tokens) && keyword(tokens[j]) != "into" {
+				i = c.table(tokens, j, false)
+			}
+		case "table", "view":
+			if kind == DDL && i > 0 {
+				j := skip(tokens, i+1, "if", "not", "exists", "only")
+				i = c.tables(tokens, j, false, true)
+			}
+		case "truncate":
+			if i == 0 {
+				j := skip(tokens, i+1, "table", "only")
+				i = c.tables(tokens, j, false, true)
+			}
+		case "on":
+			if kind == DDL && c.index(tokens[:i]) {
+				i = c.table(tokens, skip(tokens, i+1, "only"), false)
+			}
+		}
+	}
+	return kind
+}
+
+// starts reports whether tokens[i] starts a statement: it's the first token,
+// or follows a parenthesis, like the statements of common table expressions
+// and the ones following them.
+func starts(tokens []sqlscan.Token, i int) bool {
+	return i == 0 || tokens[i-1].Text == "(" || tokens[i-1].Text == ")"
+}
+
+// with records the names of the common table expressions of tokens, and
+// returns the kind of the statement using them.
+func (c *classifier) with(tokens []sqlscan.Token) Kind {
+	depth := 0
+	for i, tok := range tokens {
+		switch tok.Text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+
+		switch k := keyword(tok); {
+		case k == "as" && i > 0:
+			j := i - 1
+			if tokens[j].Text == ")" {
+				// WITH name(columns) AS
+				for j > 0 && tokens[j].Text != "(" {
+					j--
+				}
+				j--
+			}
+			if j >= 0 {
+				c.ctes[strings.ToLower(tokens[j].Name)] = true
+			}
+		case kinds[k] != Other && k != "values" && k != "table":
+			return kinds[k]
+		}
+	}
+	return Other
+}
+
+// copy classifies COPY table FROM, which inserts rows, and COPY table TO,
+// which reads them.
+func (c *classifier) copy(tokens []sqlscan.Token) Kind {
+	depth := 0
+	for _, tok := range tokens[2:] {
+		switch {
+		case tok.Text == "(":
+			depth++
+		case tok.Text == ")":
+			depth--
+		case depth == 0 && keyword(tok) == "from":
+			c.table(tokens, 1, false)
+			return Insert
+		case depth == 0 && keyword(tok) == "to":
+			c.table(tokens, 1, true)
+			return Select
+		}
+	}
+	return Other
+}
+
+// deleted records the tables of a MySQL multi-table DELETE, listed from
+// tokens[i] up to FROM, i.e: DELETE a, b.* FROM. It returns the index of the
+// last token read.
+func (c *classifier) deleted(tokens []sqlscan.Token, i int) int {
+	for {
+		i = c.table(tokens, i, false)
+		if i+2 < len(tokens) && tokens[i+1].Text == "." && tokens[i+2].Text == "*" {
+			i += 2
+		}
+		if i+1 >= len(tokens) || tokens[i+1].Text != "," {
+			return i
+		}
+		i += 2
+	}
+}
+
+// index reports whether tokens define an index, which is created ON a table.
+func (c *classifier) index(tokens []sqlscan.Token) bool {
+	for _, tok := range tokens {
+		if keyword(tok) == "index" {
+			return true
+		}
+	}
+	return false
+}
+
+// tables records the table starting at tokens[i], and the ones following it
+// separated by commas if list is true. It returns the index of the last token
+// read.
+func (c *classifier) tables(tokens []sqlscan.Token, i int, read, list bool) int {
+	for {
+		i = c.table(tokens, i, read)
+		// skip the alias
+		j := i + 1
+		if j < len(tokens) && keyword(tokens[j]) == "as" {
+			j++
+		}
+		if j < len(tokens) && tokens[j].Kind == sqlscan.Word && !reserved[keyword(tokens[j])] {
+			i = j
+			j++
+		}
+		if j >= len(tokens) || tokens[j].Text != "," || !list {
+			return i
+		}
+		i = j + 1
+	}
+}
+
+// table records the table named at tokens[i], if any, and returns the index
+// of its last token.
+func (c *classifier) table(tokens []sqlscan.Token, i int, read bool) int {
+	if i >= len(tokens) || tokens[i].Kind != sqlscan.Word || reserved[keyword(tokens[i])] {
+		return i - 1
+	}
+
+	name := tokens[i].Name
+	for i+2 < len(tokens) && tokens[i+1].Text == "." && tokens[i+2].Kind == sqlscan.Word {
+		name += "." + tokens[i+2].Name
+		i += 2
+	}
+	if i+1 < len(tokens) && tokens[i+1].Text == "(" && read {
+		// table valued function
+		return i
+	}
+	if !tokens[i].Quoted && c.ctes[strings.ToLower(name)] {
+		return i
+	}
+
+	tables := &c.stmt.Writes
+	key := "w" + name
+	if read {
+		tables, key = &c.stmt.Reads, "r"+name
+	}
+	if !c.seen[key] {
+		c.seen[key] = true
+		*tables = append(*tables, name)
+	}
+	return i
+}
+
+// reserved keywords can't be table names or aliases.
+var reserved = map[string]bool{
+	"select": true, "where": true, "join": true, "inner": true, "left": true,
+	"right": true, "full": true, "outer": true, "cross": true, "natural": true,
+	"on": true, "using": true, "group": true, "order": true, "having": true,
+	"limit": true, "offset": true, "union": true, "except": true,
+	"intersect": true, "set": true, "values": true, "returning": true,
+	"for": true, "window": true, "lateral": true, "as": true, "from": true,
+	"default": true, "straight_join": true, "fetch": true, "into": true,
+	"only": true, "if": true, "exists": true, "cascade": true,
+	"outfile": true, "dumpfile": true,
+}
+
+// skip returns the index of the first token from tokens[i] that isn't one of
+// the keywords.
+func skip(tokens []sqlscan.Token, i int, keywords ...string) int {
+	for ; i < len(tokens); i++ {
+		k := keyword(tokens[i])
+		found := false
+		for _, kw := range keywords {
+			found = found || k == kw
+		}
+		if !found {
+			return i
+		}
+	}
+	return i
+}
+
+// keyword returns tok in lowercase if it's an unquoted word.
+func keyword(tok sqlscan.Token) string {
+	if tok.Kind != sqlscan.Word || tok.Quoted {
+		return ""
+	}
+	return strings.ToLower(tok.Text)
+}
@@ -0,0 +1,122 @@
+package classifier
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	for _, it := range []struct {
+		query string
+		want  Statement
+	}{
+		{"SELECT 1", Statement{Kind: Select, Kinds: []Kind{Select}}},
+		{"SELECT * FROM users u JOIN orders o ON o.user_id = u.id WHERE u.id = ?",
+			Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"users", "orders"}}},
+		{"select a.x, b.y from public.a, b as bb left outer join c using (id)",
+			Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"public.a", "b", "c"}}},
+		{"SELECT * FROM t WHERE id IN (SELECT id FROM u) AND extract(year from created) = 2020",
+			Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"t", "u"}}},
+		{"SELECT * FROM generate_series(1, 10)", Statement{Kind: Select, Kinds: []Kind{Select}}},
+		{`SELECT * FROM "My Table" WHERE x = 'FROM fake'`, Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"My Table"}}},
+		{"INSERT INTO users(id) VALUES (1)", Statement{Kind: Insert, Kinds: []Kind{Insert}, Writes: []string{"users"}}},
+		{"INSERT INTO archive SELECT * FROM users", Statement{Kind: Insert, Kinds: []Kind{Insert}, Reads: []string{"users"}, Writes: []string{"archive"}}},
+		{"INSERT IGNORE users VALUES (1) ON DUPLICATE KEY UPDATE id = 1", Statement{Kind: Insert, Kinds: []Kind{Insert}, Writes: []string{"users"}}},
+		{"REPLACE INTO kv VALUES (?, ?)", Statement{Kind: Insert, Kinds: []Kind{Insert}, Writes: []string{"kv"}}},
+		{"UPDATE users SET name = ? WHERE id = ?", Statement{Kind: Update, Kinds: []Kind{Update}, Writes: []string{"users"}}},
+		{"UPDATE users u SET total = o.total FROM orders o WHERE o.user_id = u.id",
+			Statement{Kind: Update, Kinds: []Kind{Update}, Reads: []string{"orders"}, Writes: []string{"users"}}},
+		{"DELETE FROM sessions WHERE expires < now()", Statement{Kind: Delete, Kinds: []Kind{Delete}, Writes: []string{"sessions"}}},
+		{"DELETE FROM a USING b WHERE a.id = b.id", Statement{Kind: Delete, Kinds: []Kind{Delete}, Reads: []string{"b"}, Writes: []string{"a"}}},
+		{"SELECT * FROM users FOR UPDATE", Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"users"}}},
+		{"WITH recent AS (SELECT * FROM orders) SELECT * FROM recent JOIN users ON true",
+			Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"orders", "users"}}},
+		{"WITH gone(id) AS (DELETE FROM users RETURNING id) INSERT INTO audit SELECT id FROM gone",
+			Statement{Kind: Insert, Kinds: []Kind{Insert}, Reads: nil, Writes: []string{"users", "audit"}}},
+		{"WITH x AS (SELECT 1) UPDATE t SET a = 1", Statement{Kind: Update, Kinds: []Kind{Update}, Writes: []string{"t"}}},
+		{"WITH x AS (UPDATE users SET a = 1 RETURNING *) SELECT * FROM x",
+			Statement{Kind: Select, Kinds: []Kind{Select}, Writes: []string{"users"}}},
+		{"DELETE a FROM a JOIN b ON a.id = b.id", Statement{Kind: Delete, Kinds: []Kind{Delete}, Reads: []string{"a", "b"}, Writes: []string{"a"}}},
+		{"DELETE QUICK a.*, b FROM a JOIN b ON a.id = b.id", Statement{Kind: Delete, Kinds: []Kind{Delete}, Reads: []string{"a", "b"}, Writes: []string{"a", "b"}}},
+		{"CREATE TABLE o (u int REFERENCES users ON DELETE RESTRICT ON UPDATE NO ACTION)",
+			Statement{Kind: DDL, Kinds: []Kind{DDL}, Writes: []string{"o"}}},
+		{"CREATE TABLE IF NOT EXISTS users (id int REFERENCES accounts)", Statement{Kind: DDL, Kinds: []Kind{DDL}, Writes: []string{"users"}}},
+		{"CREATE TABLE copy AS SELECT * FROM users", Statement{Kind: DDL, Kinds: []Kind{DDL}, Reads: []string{"users"}, Writes: []string{"copy"}}},
+		{"CREATE UNIQUE INDEX idx ON users USING btree (email)", Statement{Kind: DDL, Kinds: []Kind{DDL}, Writes: []string{"users"}}},
+		{"DROP TABLE a, b CASCADE", Statement{Kind: DDL, Kinds: []Kind{DDL}, Writes: []string{"a", "b"}}},
+		{"ALTER TABLE users ADD COLUMN age int", Statement{Kind: DDL, Kinds: []Kind{DDL}, Writes: []string{"users"}}},
+		{"TRUNCATE logs", Statement{Kind: DDL, Kinds: []Kind{DDL}, Writes: []string{"logs"}}},
+		{"BEGIN", Statement{Kind: TCL, Kinds: []Kind{TCL}}},
+		{"SET TRANSACTION ISOLATION LEVEL SERIALIZABLE", Statement{Kind: TCL, Kinds: []Kind{TCL}}},
+		{"ROLLBACK TO SAVEPOINT sp", Statement{Kind: TCL, Kinds: []Kind{TCL}}},
+		{"PRAGMA foreign_keys = ON", Statement{Kind: Other, Kinds: []Kind{Other}}},
+		{"", Statement{Kind: Other}},
+		{"SELECT 1;", Statement{Kind: Select, Kinds: []Kind{Select}}},
+		{"SELECT * FROM a; DROP TABLE b", Statement{Kind: Select, Kinds: []Kind{Select, DDL}, Reads: []string{"a"}, Writes: []string{"b"}, Multi: true}},
+		{"MERGE INTO users u USING staged s ON s.id = u.id WHEN MATCHED THEN UPDATE SET name = s.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (s.id, s.name)",
+			Statement{Kind: Merge, Kinds: []Kind{Merge}, Reads: []string{"staged"}, Writes: []string{"users"}}},
+		{"COPY users FROM STDIN", Statement{Kind: Insert, Kinds: []Kind{Insert}, Writes: []string{"users"}}},
+		{"COPY users TO STDOUT", Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"users"}}},
+		{"COPY (SELECT * FROM users) TO '/tmp/users'", Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"users"}}},
+		{"LOAD DATA LOCAL INFILE 'users.csv' REPLACE INTO TABLE users", Statement{Kind: Insert, Kinds: []Kind{Insert}, Writes: []string{"users"}}},
+		{"LOAD 'plugin'", Statement{Kind: Other, Kinds: []Kind{Other}}},
+		{"SELECT * INTO backup FROM users", Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"users"}, Writes: []string{"backup"}}},
+		{"SELECT * INTO TEMP TABLE backup FROM users", Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"users"}, Writes: []string{"backup"}}},
+		{"SELECT id INTO @id FROM users", Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"users"}}},
+		{"SELECT * FROM users INTO OUTFILE '/tmp/users'", Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"users"}}},
+		{"CALL purge_users()", Statement{Kind: Call, Kinds: []Kind{Call}}},
+		{"EXEC purge_users", Statement{Kind: Call, Kinds: []Kind{Call}}},
+		{"SELECT ';' FROM a -- ; DROP TABLE b", Statement{Kind: Select, Kinds: []Kind{Select}, Reads: []string{"a"}}},
+	} {
+		assert.Equal(t, it.want, Classify(it.query), it.query)
+	}
+}
+
+func TestIsWrite(t *testing.T) {
+	assert.False(t, Classify("SELECT * FROM users").IsWrite())
+	assert.False(t, Classify("BEGIN").IsWrite())
+	assert.True(t, Classify("UPDATE users SET a = 1").IsWrite())
+	assert.True(t, Classify("CREATE TABLE t(id int)").IsWrite())
+	assert.True(t, Classify("SELECT 1; DELETE FROM t").IsWrite())
+	assert.True(t, Classify("SELECT 1; DROP DATABASE prod").IsWrite())
+	assert.True(t, Classify("MERGE INTO t USING s ON true WHEN MATCHED THEN DELETE").IsWrite())
+	assert.True(t, Classify("CALL purge_users()").IsWrite())
+	assert.True(t, Classify("SELECT * INTO backup FROM users").IsWrite())
+	assert.False(t, Classify("SELECT 1; SELECT 2").IsWrite())
+}
+
+func TestDialect(t *testing.T) {
+	c := New(MySQL, 10)
+	assert.Equal(t, []string{"t"}, c.Classify(`SELECT "FROM x" FROM t`).Reads)
+	assert.Equal(t, []string{"my table"}, c.Classify("SELECT * FROM `my table`").Reads)
+}
+
+func TestCache(t *testing.T) {
+	c := New(0, 2)
+	for i := 0; i < 3; i++ {
+		c.Classify(fmt.Sprintf("SELECT * FROM t%d", i))
+	}
+	assert.Equal(t, 2, c.lru.Len())
+	assert.Len(t, c.cache, 2)
+	assert.NotContains(t, c.cache, "SELECT * FROM t0")
+
+	a := c.Classify("SELECT * FROM t2")
+	assert.Equal(t, []string{"t2"}, a.Reads)
+}
+
+func BenchmarkClassify(b *testing.B) {
+	query := "SELECT * FROM users u JOIN orders o ON o.user_id = u.id WHERE u.id = ? AND o.status IN (?, ?, ?)"
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Classify(query)
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		c := New(0, 0)
+		for i := 0; i < b.N; i++ {
+			c.Classify(query)
+		}
+	})
+}
@@ -2,7 +2,9 @@ package sqlhooks
 
 import (
 	"context"
-	"fmt"
+	"database/sql/driver"
+	"errors"
+	"strings"
 )
 
 // Compose allows for composing multiple Hooks into one.
@@ -10,6 +12,8 @@ import (
 // even if previous hooks return an error.
 // If multiple hooks return errors, the error return value will be
 // MultipleErrors, which allows for introspecting the errors if necessary.
+// The error passed to OnError is always reachable through errors.Is and
+// errors.As on the returned error, even if the hooks replace it.
 func Compose(hooks ...Hooks) Hooks {
 	return composed(hooks)
 }
@@ -17,60 +21,135 @@ func Compose(hooks ...Hooks) Hooks {
 type composed []Hooks
 
 func (c composed) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
-	var errors []error
+	var errs []error
 	for _, hook := range c {
 		c, err := hook.Before(ctx, query, args...)
 		if err != nil {
-			errors = append(errors, err)
+			errs = append(errs, err)
 		}
 		if c != nil {
 			ctx = c
 		}
 	}
-	return ctx, wrapErrors(nil, errors)
+	return ctx, wrapErrors(nil, errs)
 }
 
 func (c composed) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
-	var errors []error
+	var errs []error
 	for _, hook := range c {
-		var err error
 		c, err := hook.After(ctx, query, args...)
 		if err != nil {
-			errors = append(errors, err)
+			errs = append(errs, err)
 		}
 		if c != nil {
 			ctx = c
 		}
 	}
-	return ctx, wrapErrors(nil, errors)
+	return ctx, wrapErrors(nil, errs)
 }
 
 func (c composed) OnError(ctx context.Context, cause error, query string, args ...interface{}) error {
-	var errors []error
+	var errs []error
 	for _, hook := range c {
 		if onErrorer, ok := hook.(OnErrorer); ok {
 			if err := onErrorer.OnError(ctx, cause, query, args...); err != nil && err != cause {
-				errors = append(errors, err)
+				errs = append(errs, err)
 			}
 		}
 	}
-	return wrapErrors(cause, errors)
+
+	// Hooks may replace the cause with an error of their own, make sure the
+	// original one is still reachable so callers can inspect it.
+	if len(errs) > 0 && cause != nil && !reaches(errs, cause) {
+		errs = append(errs, cause)
+	}
+	return wrapErrors(cause, errs)
+}
+
+func (c composed) OnSkip(ctx context.Context, query string, args ...interface{}) {
+	for _, hook := range c {
+		if onSkipper, ok := hook.(OnSkipper); ok {
+			onSkipper.OnSkip(ctx, query, args...)
+		}
+	}
+}
+
+func (c composed) OnRowsClose(ctx context.Context, query string, rows int, err error) {
+	for _, hook := range c {
+		if onRowsCloser, ok := hook.(OnRowsCloser); ok {
+			onRowsCloser.OnRowsClose(ctx, query, rows, err)
+		}
+	}
+}
+
+func (c composed) BeginTx(ctx context.Context, opts driver.TxOptions) (context.Context, error) {
+	var errs []error
+	for _, hook := range c {
+		if txHooks, ok := hook.(TxHooks); ok {
+			c, err := txHooks.BeginTx(ctx, opts)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if c != nil {
+				ctx = c
+			}
+		}
+	}
+	return ctx, wrapErrors(nil, errs)
 }
 
-func wrapErrors(def error, errors []error) error {
-	switch len(errors) {
+func (c composed) EndTx(ctx context.Context, end TxEnd, cause error) error {
+	var errs []error
+	for _, hook := range c {
+		if txHooks, ok := hook.(TxHooks); ok {
+			if err := txHooks.EndTx(ctx, end, cause); err != nil && err != cause {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if len(errs) > 0 && cause != nil && !reaches(errs, cause) {
+		errs = append(errs, cause)
+	}
+	return wrapErrors(cause, errs)
+}
+
+// reaches reports whether target is found in the chain of any of errs.
+func reaches(errs []error, target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func wrapErrors(def error, errs []error) error {
+	switch len(errs) {
 	case 0:
 		return def
 	case 1:
-		return errors[0]
+		return errs[0]
 	default:
-		return MultipleErrors(errors)
+		return MultipleErrors(errs)
 	}
 }
 
 // MultipleErrors is an error that contains multiple errors.
+// It implements Unwrap() []error, so errors.Is and errors.As inspect every
+// error it contains.
 type MultipleErrors []error
 
+// Error formats the errors in order on a single line, separated by "; ".
 func (m MultipleErrors) Error() string {
-	return fmt.Sprint("multiple errors:", []error(m))
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+	return "multiple errors: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the contained errors.
+func (m MultipleErrors) Unwrap() []error {
+	return []error(m)
 }
@@ -3,6 +3,7 @@ package sqlhooks
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -34,15 +35,17 @@ var (
 )
 
 func TestCompose(t *testing.T) {
+	cause := errors.New("crikey")
 	for _, it := range []struct {
-		name  string
-		hooks Hooks
-		want  error
+		name        string
+		hooks       Hooks
+		want        error
+		wantOnError error
 	}{
-		{"happy case", Compose(okHook, okHook), nil},
-		{"no hooks", Compose(), nil},
-		{"multiple errors", Compose(oopsHook, okHook, oopsHook), MultipleErrors([]error{oops, oops})},
-		{"single error", Compose(okHook, oopsHook, okHook), oops},
+		{"happy case", Compose(okHook, okHook), nil, cause},
+		{"no hooks", Compose(), nil, cause},
+		{"multiple errors", Compose(oopsHook, okHook, oopsHook), MultipleErrors([]error{oops, oops}), MultipleErrors([]error{oops, oops, cause})},
+		{"single error", Compose(okHook, oopsHook, okHook), oops, MultipleErrors([]error{oops, cause})},
 	} {
 		t.Run(it.name, func(t *testing.T) {
 			t.Run("Before", func(t *testing.T) {
@@ -58,14 +61,12 @@ func TestCompose(t *testing.T) {
 				}
 			})
 			t.Run("OnError", func(t *testing.T) {
-				cause := errors.New("crikey")
-				want := it.want
-				if want == nil {
-					want = cause
-				}
 				got := it.hooks.(OnErrorer).OnError(context.Background(), cause, "query")
-				if !reflect.DeepEqual(want, got) {
-					t.Errorf("unexpected error. want: %q, got: %q", want, got)
+				if !reflect.DeepEqual(it.wantOnError, got) {
+					t.Errorf("unexpected error. want: %q, got: %q", it.wantOnError, got)
+				}
+				if !errors.Is(got, cause) {
+					t.Errorf("cause is not reachable from %q", got)
 				}
 			})
 		})
@@ -94,3 +95,42 @@ func TestWrapErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestComposeOnErrorWrappedCause(t *testing.T) {
+	cause := errors.New("crikey")
+	wrapping := &testHooks{
+		onError: func(ctx context.Context, err error, query string, args ...interface{}) error {
+			return fmt.Errorf("wrapped: %w", err)
+		},
+	}
+
+	got := Compose(okHook, wrapping).(OnErrorer).OnError(context.Background(), cause, "query")
+	if want := "wrapped: crikey"; got.Error() != want {
+		t.Errorf("unexpected error. want: %q, got: %q", want, got)
+	}
+}
+
+type codeError struct{ code int }
+
+func (e *codeError) Error() string { return fmt.Sprintf("code %d", e.code) }
+
+func TestMultipleErrors(t *testing.T) {
+	cause := &codeError{1062}
+	err := error(MultipleErrors{oops, fmt.Errorf("hook: %w", context.Canceled), cause})
+
+	if want, got := "multiple errors: oops; hook: context canceled; code 1062", err.Error(); want != got {
+		t.Errorf("unexpected message. want: %q, got: %q", want, got)
+	}
+
+	if !errors.Is(err, oops) {
+		t.Errorf("errors.Is(err, oops) = false")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("errors.Is(err, context.Canceled) = false")
+	}
+
+	var target *codeError
+	if !errors.As(err, &target) || target != cause {
+		t.Errorf("errors.As did not find %v in %v", cause, err)
+	}
+}
@@ -0,0 +1,99 @@
+package sqlhooks
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// call holds the details of a statement hooks are being called for.
+type call struct {
+	op       Op
+	instance *Instance
+	names    []string
+	tx       context.Context
+	// result is the result of exec calls, set before calling After.
+	result driver.Result
+	// attempt is the attempt number of retried statements, see WithRetry.
+	attempt int
+}
+
+type callKey struct{}
+
+func withCall(ctx context.Context, conn *Conn, op Op, args []driver.NamedValue) context.Context {
+	c := &call{op: op, names: argNames(args)}
+	if conn != nil {
+		c.tx = conn.tx
+		if conn.opts != nil {
+			c.instance = conn.opts.instance
+		}
+	}
+	return context.WithValue(ctx, callKey{}, c)
+}
+
+func callFromContext(ctx context.Context) *call {
+	c, _ := ctx.Value(callKey{}).(*call)
+	if c == nil {
+		return &call{}
+	}
+	return c
+}
+
+// OpFromContext returns the operation running the statement hooks are being
+// called for, or an empty Op if ctx doesn't belong to a statement.
+func OpFromContext(ctx context.Context) Op {
+	return callFromContext(ctx).op
+}
+
+// TxFromContext returns the context returned by TxHooks.BeginTx for the
+// transaction the statement hooks are being called for runs in. ok is false
+// if the statement doesn't run in a transaction.
+func TxFromContext(ctx context.Context) (tx context.Context, ok bool) {
+	tx = callFromContext(ctx).tx
+	return tx, tx != nil
+}
+
+// ResultFromContext returns the driver.Result of the exec call the After hook
+// is being called for. ok is false for queries, and for hooks other than
+// After.
+func ResultFromContext(ctx context.Context) (result driver.Result, ok bool) {
+	result = callFromContext(ctx).result
+	return result, result != nil
+}
+
+func setResult(ctx context.Context, result driver.Result) {
+	if c, ok := ctx.Value(callKey{}).(*call); ok {
+		c.result = result
+	}
+}
+
+// InstanceFromContext returns the Instance of the driver running the query
+// or the transaction, ok is false if the driver was not wrapped using WithInstance.
+// The returned Labels must not be modified.
+func InstanceFromContext(ctx context.Context) (instance Instance, ok bool) {
+	i := callFromContext(ctx).instance
+	if i == nil {
+		return Instance{}, false
+	}
+	return *i, true
+}
+
+// ArgNames returns the names of the arguments hooks are being called with,
+// in the same order. Arguments that were not passed using sql.Named have an
+// empty name. It returns nil if none of the arguments are named.
+func ArgNames(ctx context.Context) []string {
+	return callFromContext(ctx).names
+}
+
+func argNames(args []driver.NamedValue) []string {
+	var names []string
+	for i, a := range args {
+		if a.Name == "" {
+			continue
+		}
+		if names == nil {
+			names = make([]string, len(args))
+		}
+		names[i] = a.Name
+	}
+	return names
+}
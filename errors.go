@@ -0,0 +1,48 @@
+package sqlhooks
+
+import (
+	"fmt"
+	"time"
+)
+
+// Op identifies the driver operation that ran a query.
+type Op string
+
+const (
+	// OpExec is a statement run through ExecContext.
+	OpExec Op = "exec"
+	// OpQuery is a statement run through QueryContext.
+	OpQuery Op = "query"
+	// OpStmtExec is a prepared statement run through ExecContext.
+	OpStmtExec Op = "stmt.exec"
+	// OpStmtQuery is a prepared statement run through QueryContext.
+	OpStmtQuery Op = "stmt.query"
+)
+
+// QueryError is returned for driver failures when the driver has been wrapped
+// using WithQueryError. It unwraps to the error returned by the driver (or
+// the OnError hooks), so errors.Is and errors.As keep working.
+type QueryError struct {
+	// Op is the operation that failed.
+	Op Op
+	// Query is the statement, redacted if a redactor was given.
+	Query string
+	// NumArgs is the number of arguments the statement was run with.
+	NumArgs int
+	// Duration is how long the driver took before failing.
+	Duration time.Duration
+	// ConnID identifies the connection the statement was run on.
+	ConnID uint64
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s `%s` (args: %d, conn: %d, took: %s): %v",
+		e.Op, e.Query, e.NumArgs, e.ConnID, e.Duration, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
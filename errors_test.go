@@ -0,0 +1,99 @@
+package sqlhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryError(t *testing.T) {
+	db := openDB(t, newTestHooks(), WithQueryError(nil))
+
+	_, err := db.Exec("SELECT * FROM missing WHERE id = ?", 1)
+	require.Error(t, err)
+
+	var qe *QueryError
+	require.True(t, errors.As(err, &qe), "%T is not a *QueryError", err)
+	assert.Equal(t, OpExec, qe.Op)
+	assert.Equal(t, "SELECT * FROM missing WHERE id = ?", qe.Query)
+	assert.Equal(t, 1, qe.NumArgs)
+	assert.NotZero(t, qe.ConnID)
+	assert.Contains(t, qe.Error(), "SELECT * FROM missing WHERE id = ?")
+
+	var sqliteErr sqlite3.Error
+	require.True(t, errors.As(err, &sqliteErr), "driver error is not reachable")
+	assert.Equal(t, sqlite3.ErrError, sqliteErr.Code)
+
+	t.Run("Statements", func(t *testing.T) {
+		_, err := db.Exec("CREATE TABLE users(id int PRIMARY KEY)")
+		require.NoError(t, err)
+
+		stmt, err := db.Prepare("INSERT INTO users(id) VALUES(?)")
+		require.NoError(t, err)
+		defer stmt.Close()
+
+		_, err = stmt.Exec(1)
+		require.NoError(t, err)
+		_, err = stmt.Exec(1)
+		require.True(t, errors.As(err, &qe), "%T is not a *QueryError", err)
+		assert.Equal(t, OpStmtExec, qe.Op)
+		assert.Equal(t, "INSERT INTO users(id) VALUES(?)", qe.Query)
+	})
+}
+
+func TestQueryErrorRedact(t *testing.T) {
+	db := openDB(t, newTestHooks(), WithQueryError(func(string) string { return "<redacted>" }))
+
+	_, err := db.Query("SELECT 'secret' FROM missing")
+	var qe *QueryError
+	require.True(t, errors.As(err, &qe), "%T is not a *QueryError", err)
+	assert.Equal(t, OpQuery, qe.Op)
+	assert.Equal(t, "<redacted>", qe.Query)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestQueryErrorDisabled(t *testing.T) {
+	db := openDB(t, newTestHooks())
+
+	_, err := db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+
+	var qe *QueryError
+	assert.False(t, errors.As(err, &qe), "QueryError returned without WithQueryError")
+}
+
+func TestQueryErrorDuration(t *testing.T) {
+	hooks := newTestHooks()
+	hooks.onError = func(ctx context.Context, err error, query string, args ...interface{}) error {
+		time.Sleep(100 * time.Millisecond)
+		return err
+	}
+	db := openDB(t, hooks, WithQueryError(nil))
+
+	_, err := db.Exec("SELECT * FROM missing")
+	var qe *QueryError
+	require.True(t, errors.As(err, &qe), "%T is not a *QueryError", err)
+	assert.Less(t, qe.Duration, 100*time.Millisecond, "the time spent in OnError is not the driver's")
+}
+
+func TestQueryErrorHookError(t *testing.T) {
+	boom := errors.New("boom")
+	hooks := newTestHooks()
+	hooks.onError = func(ctx context.Context, err error, query string, args ...interface{}) error {
+		return fmt.Errorf("%v: %w", boom, err)
+	}
+	db := openDB(t, hooks, WithQueryError(nil))
+
+	_, err := db.Exec("SELECT * FROM missing")
+	var qe *QueryError
+	require.True(t, errors.As(err, &qe), "%T is not a *QueryError", err)
+
+	var sqliteErr sqlite3.Error
+	assert.True(t, errors.As(err, &sqliteErr), "driver error is not reachable")
+}
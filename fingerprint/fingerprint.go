@@ -0,0 +1,251 @@
+// Package fingerprint normalizes SQL statements so that the ones differing
+// only by their literals, comments, whitespace or case are grouped together.
+//
+//	fingerprint.Normalize(fingerprint.Postgres, "SELECT * FROM users WHERE id IN (1, 2, 3) -- list")
+//	// select * from users where id in(?+)
+//
+// It's meant to be used from hooks, to aggregate queries:
+//
+//	func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+//		h.count[fingerprint.Of(fingerprint.MySQL, query).Hash]++
+//		return ctx, nil
+//	}
+package fingerprint
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqlscan"
+)
+
+// Dialect defines the quoting rules of statements. The zero Dialect accepts
+// the syntax of every dialect.
+type Dialect int
+
+const (
+	// MySQL quotes strings with ' and ", identifiers with `, and escapes
+	// quotes with backslashes.
+	MySQL Dialect = iota + 1
+	// Postgres quotes strings with ' and $$, and identifiers with ".
+	Postgres
+	// SQLite quotes strings with ', and identifiers with ", ` and [].
+	SQLite
+)
+
+// List replaces the literals of IN lists.
+const List = "?+"
+
+// Fingerprint identifies a group of statements.
+type Fingerprint struct {
+	// Query is the normalized statement.
+	Query string
+	// Hash is the FNV-1a hash of Query.
+	Hash uint64
+}
+
+// String returns Hash in hexadecimal.
+func (f Fingerprint) String() string {
+	return fmt.Sprintf("%016x", f.Hash)
+}
+
+// Of returns the Fingerprint of query.
+func Of(dialect Dialect, query string) Fingerprint {
+	normalized := Normalize(dialect, query)
+	return Fingerprint{Query: normalized, Hash: Hash(normalized)}
+}
+
+// Hash returns the FNV-1a hash of a normalized statement. It's stable across
+// processes and versions of the package, as long as the normalization rules
+// don't change.
+func Hash(normalized string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(normalized))
+	return h.Sum64()
+}
+
+// Normalize returns query with:
+//
+//   - string, numeric and blob literals, and placeholders replaced with ?.
+//   - IN lists of literals replaced with (?+).
+//   - VALUES with identical tuples reduced to the first one.
+//   - comments and the trailing semicolon removed.
+//   - unquoted keywords and identifiers in lowercase.
+//   - a single space between tokens, except around punctuation.
+//
+// Quoted identifiers are left untouched.
+func Normalize(dialect Dialect, query string) string {
+	parts := collapseValues(collapseIn(literals(dialect, query)))
+	if n := len(parts); n > 0 && parts[n-1] == ";" {
+		parts = parts[:n-1]
+	}
+	return join(parts)
+}
+
+// literals returns the tokens of query, with literals replaced and comments
+// and whitespace removed.
+func literals(dialect Dialect, query string) []string {
+	var parts []string
+	for _, tok := range sqlscan.Scan(sqlscan.Dialect(dialect), query) {
+		switch tok.Kind {
+		case sqlscan.Space, sqlscan.Comment:
+			continue
+		case sqlscan.String, sqlscan.Placeholder:
+			parts = append(parts, "?")
+		case sqlscan.Number:
+			if n := len(parts); n > 0 && (parts[n-1] == "-" || parts[n-1] == "+") && unary(parts[:n-1]) {
+				parts = parts[:n-1]
+			}
+			parts = append(parts, "?")
+		case sqlscan.Word:
+			if tok.Quoted {
+				parts = append(parts, tok.Text)
+			} else {
+				parts = append(parts, strings.ToLower(tok.Text))
+			}
+		default:
+			parts = append(parts, tok.Text)
+		}
+	}
+	return parts
+}
+
+// keywords are the ones a unary sign may follow.
+var keywords = map[string]bool{
+	"select": true, "where": true, "and": true, "or": true, "not": true,
+	"in": true, "values": true, "set": true, "when": true, "then": true,
+	"else": true, "between": true, "limit": true, "offset": true, "is": true,
+	"like": true, "on": true, "having": true, "return": true, "by": true,
+}
+
+// unary reports whether a sign following parts is unary.
+func unary(parts []string) bool {
+	if len(parts) == 0 {
+		return true
+	}
+	last := parts[len(parts)-1]
+	switch {
+	case last == ")" || last == "?":
+		return false
+	case keywords[last]:
+		return true
+	}
+	return !isWord(last)
+}
+
+// collapseIn replaces IN lists of literals.
+func collapseIn(parts []string) []string {
+	out := parts[:0:0]
+	for i := 0; i < len(parts); i++ {
+		out = append(out, parts[i])
+		if parts[i] != "in" || i+1 >= len(parts) || parts[i+1] != "(" {
+			continue
+		}
+
+		j := i + 2
+		for j+1 < len(parts) && parts[j] == "?" && parts[j+1] == "," {
+			j += 2
+		}
+		if j+1 < len(parts) && parts[j] == "?" && parts[j+1] == ")" {
+			out = append(out, "(", List, ")")
+			i = j + 1
+		}
+	}
+	return out
+}
+
+// collapseValues reduces VALUES lists of identical tuples to the first one.
+func collapseValues(parts []string) []string {
+	out := parts[:0:0]
+	for i := 0; i < len(parts); i++ {
+		out = append(out, parts[i])
+		if parts[i] != "values" && parts[i] != "value" {
+			continue
+		}
+
+		first := tuple(parts, i+1)
+		if first == 0 {
+			continue
+		}
+		end := i + 1 + first
+		for end < len(parts) && parts[end] == "," {
+			n := tuple(parts, end+1)
+			if n != first || !equal(parts[i+1:i+1+first], parts[end+1:end+1+n]) {
+				break
+			}
+			end += 1 + n
+		}
+		if end < len(parts) && parts[end] == "," {
+			// tuples differ, keep them all
+			continue
+		}
+		out = append(out, parts[i+1:i+1+first]...)
+		i = end - 1
+	}
+	return out
+}
+
+// tuple returns the length of the parenthesized tuple starting at parts[i],
+// or 0 if there's none.
+func tuple(parts []string, i int) int {
+	if i >= len(parts) || parts[i] != "(" {
+		return 0
+	}
+	depth := 0
+	for j := i; j < len(parts); j++ {
+		switch parts[j] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return j - i + 1
+			}
+		}
+	}
+	return 0
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func join(parts []string) string {
+	var b strings.Builder
+	for i, p := range parts {
+		if i > 0 && space(parts[i-1], p) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// space reports whether a space separates the tokens prev and next.
+func space(prev, next string) bool {
+	switch next {
+	case ")", ",", ".", "::", ";":
+		return false
+	case "(":
+		return prev == ","
+	}
+	switch prev {
+	case "(", ".", "::":
+		return false
+	}
+	return true
+}
+
+func isWord(s string) bool {
+	c := s[0]
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 || c == '"' || c == '`' || c == '['
+}
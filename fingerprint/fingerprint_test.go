@@ -0,0 +1,47 @@
+package fingerprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, it := range []struct {
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{0, "SELECT * FROM users WHERE id = 42", "select * from users where id = ?"},
+		{0, "select *\n  from Users\twhere ID=43;", "select * from users where id = ?"},
+		{0, "SELECT * FROM users WHERE id = ? -- by id", "select * from users where id = ?"},
+		{0, "/* app=api */ SELECT * FROM users WHERE id = $1", "select * from users where id = ?"},
+		{0, "SELECT * FROM t WHERE a = -1 AND b = 2-1", "select * from t where a = ? and b = ? - ?"},
+		{0, "SELECT count(*) FROM t WHERE id IN (1, 2, 3)", "select count(*) from t where id in(?+)"},
+		{0, "SELECT * FROM t WHERE id IN (?)", "select * from t where id in(?+)"},
+		{0, "SELECT * FROM t WHERE id IN (SELECT id FROM u)", "select * from t where id in(select id from u)"},
+		{0, "INSERT INTO t(a, b) VALUES (1, 'x'), (2, 'y'), (3, 'z')", "insert into t(a, b) values(?, ?)"},
+		{0, "INSERT INTO t(a, b) VALUES (1, 'x'), (2, now())", "insert into t(a, b) values(?, ?), (?, now())"},
+		{MySQL, `SELECT * FROM ` + "`Users`" + ` WHERE name = "it\"s" # comment`, "select * from `Users` where name = ?"},
+		{MySQL, `INSERT INTO t VALUES (1) ON DUPLICATE KEY UPDATE a = 'o\'k'`, "insert into t values(?) on duplicate key update a = ?"},
+		{Postgres, `SELECT "Name" FROM t WHERE body = $$it's$$ AND x = E'a\'b'`, `select "Name" from t where body = ? and x = ?`},
+		{Postgres, "SELECT a::text FROM t WHERE b = 1.5e10", "select a::text from t where b = ?"},
+		{SQLite, "SELECT [Col] FROM t WHERE b = x'00ff' AND c = :name", "select [Col] from t where b = ? and c = ?"},
+	} {
+		assert.Equal(t, it.want, Normalize(it.dialect, it.query), it.query)
+	}
+}
+
+func TestOf(t *testing.T) {
+	a := Of(Postgres, "SELECT * FROM users WHERE id = 42")
+	b := Of(Postgres, "select * from users where id=43")
+	c := Of(Postgres, "SELECT * FROM orders WHERE id = 42")
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a.Hash, c.Hash)
+	assert.Equal(t, "select * from users where id = ?", a.Query)
+	// Hashes are stored in firewall allowlists, they must not change across
+	// versions.
+	assert.Equal(t, uint64(0x281469707030c9a5), a.Hash)
+	assert.Equal(t, "281469707030c9a5", a.String())
+}
@@ -0,0 +1,161 @@
+// Package budget limits the number of queries, and the time spent running
+// them, per request.
+//
+//	sql.Register("budgeted", sqlhooks.Wrap(&pq.Driver{}, budget.New()))
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		ctx := budget.WithQueryBudget(r.Context(), 20, 200*time.Millisecond)
+//		defer func() {
+//			usage, _ := budget.UsageFromContext(ctx)
+//			log.Printf("%d queries, %s", usage.Queries, usage.Duration)
+//		}()
+//		...
+//	}
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded tells that a query was rejected because the budget of its
+// context was used up, the *Error returned then carries the usage.
+var ErrBudgetExceeded = errors.New("query budget exceeded")
+
+// Error is returned by the queries run once a budget is used up.
+type Error struct {
+	// Usage is the usage of the budget when the query was rejected.
+	Usage Usage
+}
+
+func (e *Error) Error() string {
+	if e.Usage.MaxQueries > 0 && e.Usage.Queries >= e.Usage.MaxQueries {
+		return fmt.Sprintf("%s: %d of %d queries run", ErrBudgetExceeded, e.Usage.Queries, e.Usage.MaxQueries)
+	}
+	return fmt.Sprintf("%s: %s of %s spent", ErrBudgetExceeded, e.Usage.Duration, e.Usage.MaxDuration)
+}
+
+// Is makes every *Error match ErrBudgetExceeded, whichever limit was reached.
+func (e *Error) Is(target error) bool { return target == ErrBudgetExceeded }
+
+// Usage reports how much of a budget was used.
+type Usage struct {
+	// Queries is the number of queries run, and Duration the time spent
+	// running them.
+	Queries  int
+	Duration time.Duration
+	// Rejected is the number of queries rejected because the budget was
+	// used up.
+	Rejected int
+
+	// MaxQueries and MaxDuration are the limits of the budget, 0 means no
+	// limit.
+	MaxQueries  int
+	MaxDuration time.Duration
+}
+
+// Exceeded reports whether the budget is used up.
+func (u Usage) Exceeded() bool {
+	return u.MaxQueries > 0 && u.Queries >= u.MaxQueries ||
+		u.MaxDuration > 0 && u.Duration >= u.MaxDuration
+}
+
+// budget holds the usage of a budget, shared by the queries run with it.
+type budget struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+type budgetKey struct{}
+
+// WithQueryBudget returns a copy of ctx with a budget of maxQueries queries
+// and maxDuration of time spent running them. A zero limit is not enforced.
+// Queries run with contexts derived from it share the budget, which replaces
+// any parent one.
+func WithQueryBudget(ctx context.Context, maxQueries int, maxDuration time.Duration) context.Context {
+	return context.WithValue(ctx, budgetKey{}, &budget{usage: Usage{
+		MaxQueries:  maxQueries,
+		MaxDuration: maxDuration,
+	}})
+}
+
+// UsageFromContext returns the usage of the budget of ctx, ok is false if
+// ctx has no budget.
+func UsageFromContext(ctx context.Context) (usage Usage, ok bool) {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return Usage{}, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.usage, true
+}
+
+// Hook enforces the budgets set with WithQueryBudget. Queries are rejected
+// in Before with an *Error once the budget is used up, queries running
+// already are allowed to finish.
+type Hook struct{}
+
+// New returns a new Hook.
+func New() *Hook {
+	return &Hook{}
+}
+
+type startedKey struct{}
+
+func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return ctx, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.usage.Exceeded() {
+		b.usage.Rejected++
+		return ctx, &Error{Usage: b.usage}
+	}
+	b.usage.Queries++
+	return context.WithValue(ctx, startedKey{}, time.Now()), nil
+}
+
+func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	h.spent(ctx)
+	return ctx, nil
+}
+
+func (h *Hook) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	h.spent(ctx)
+	return err
+}
+
+// OnSkip gives back the query counted for a statement the driver skipped:
+// database/sql prepares it and runs it again, which is counted on its own.
+func (h *Hook) OnSkip(ctx context.Context, query string, args ...interface{}) {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.usage.Queries--
+}
+
+// spent adds the time spent by the query to its budget.
+func (h *Hook) spent(ctx context.Context) {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	started, ok2 := ctx.Value(startedKey{}).(time.Time)
+	if !ok || !ok2 {
+		return
+	}
+
+	d := time.Since(started)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.usage.Duration += d
+}
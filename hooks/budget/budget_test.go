@@ -0,0 +1,93 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2/internal/skipdriver"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxQueries(t *testing.T) {
+	db := sqltest.Open(t, New())
+	ctx := WithQueryBudget(context.Background(), 2, 0)
+
+	for i := 0; i < 2; i++ {
+		_, err := db.ExecContext(ctx, "SELECT 1")
+		require.NoError(t, err)
+	}
+	_, err := db.ExecContext(ctx, "SELECT 1")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBudgetExceeded))
+	assert.Equal(t, "query budget exceeded: 2 of 2 queries run", err.Error())
+
+	var bErr *Error
+	require.True(t, errors.As(err, &bErr))
+	assert.Equal(t, 2, bErr.Usage.Queries)
+
+	_, err = db.QueryContext(ctx, "SELECT 1")
+	require.Error(t, err)
+
+	usage, ok := UsageFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, 2, usage.Queries)
+	assert.Equal(t, 2, usage.Rejected)
+	assert.True(t, usage.Exceeded())
+	assert.True(t, usage.Duration > 0)
+
+	// queries without a budget are not limited
+	_, err = db.Exec("SELECT 1")
+	require.NoError(t, err)
+}
+
+func TestSkippedQueries(t *testing.T) {
+	db := sqltest.OpenDriver(t, &skipdriver.Driver{}, New())
+	ctx := WithQueryBudget(context.Background(), 1, 0)
+
+	// the skipped attempt and the prepared statement use a single query
+	_, err := db.ExecContext(ctx, "INSERT INTO users VALUES(?)", 1)
+	require.NoError(t, err)
+	usage, ok := UsageFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, 1, usage.Queries)
+
+	_, err = db.ExecContext(ctx, "INSERT INTO users VALUES(?)", 2)
+	assert.True(t, errors.Is(err, ErrBudgetExceeded))
+}
+
+func TestMaxDuration(t *testing.T) {
+	db := sqltest.Open(t, New())
+	ctx := WithQueryBudget(context.Background(), 0, time.Nanosecond)
+
+	_, err := db.ExecContext(ctx, "SELECT 1")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "SELECT 1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "query budget exceeded: ")
+	assert.Contains(t, err.Error(), " of 1ns spent")
+
+	usage, _ := UsageFromContext(ctx)
+	assert.Equal(t, 1, usage.Queries)
+	assert.Equal(t, 1, usage.Rejected)
+}
+
+func TestFailedQueriesCount(t *testing.T) {
+	db := sqltest.Open(t, New())
+	ctx := WithQueryBudget(context.Background(), 1, 0)
+
+	_, err := db.ExecContext(ctx, "SELECT * FROM missing")
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrBudgetExceeded))
+
+	_, err = db.ExecContext(ctx, "SELECT 1")
+	assert.True(t, errors.Is(err, ErrBudgetExceeded))
+}
+
+func TestUsageFromContextMissing(t *testing.T) {
+	_, ok := UsageFromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,182 @@
+// Package errclass provides a hook that maps driver specific errors from
+// lib/pq, go-sql-driver/mysql and mattn/go-sqlite3 into portable sentinel
+// errors.
+package errclass
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Portable error categories. Errors returned by Hook.OnError match them
+// through errors.Is.
+var (
+	ErrUniqueViolation      = errors.New("unique violation")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrNotNullViolation     = errors.New("not null violation")
+	ErrCheckViolation       = errors.New("check violation")
+	ErrDeadlock             = errors.New("deadlock")
+	ErrSerializationFailure = errors.New("serialization failure")
+	ErrLockTimeout          = errors.New("lock timeout")
+	ErrConnectionLost       = errors.New("connection lost")
+	ErrSyntax               = errors.New("syntax error")
+)
+
+// Error is a driver error that has been classified.
+// errors.Is reports true for its Class, and Unwrap returns the driver error
+// so that errors.As on the driver specific type keeps working.
+type Error struct {
+	Class error
+	Err   error
+}
+
+func (e *Error) Error() string { return e.Class.Error() + ": " + e.Err.Error() }
+
+// Unwrap returns the driver error.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is reports whether target is the class of the error.
+func (e *Error) Is(target error) bool { return target == e.Class }
+
+// Classify returns the category of err, or nil if err is not recognized.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		pqErr     *pq.Error
+		mysqlErr  *mysql.MySQLError
+		sqliteErr sqlite3.Error
+	)
+	switch {
+	case errors.As(err, &pqErr):
+		return classifyPostgres(pqErr)
+	case errors.As(err, &mysqlErr):
+		return classifyMySQL(mysqlErr)
+	case errors.As(err, &sqliteErr):
+		return classifySQLite(sqliteErr)
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, mysql.ErrInvalidConn):
+		return ErrConnectionLost
+	}
+	return nil
+}
+
+// IsTransient reports whether err is a deadlock, a serialization failure or a
+// lock timeout: failures that usually go away when the statement is run again.
+// It is meant to be used as sqlhooks.RetryPolicy.Retryable.
+func IsTransient(err error) bool {
+	switch Classify(err) {
+	case ErrDeadlock, ErrSerializationFailure, ErrLockTimeout:
+		return true
+	}
+	return false
+}
+
+func classifyPostgres(err *pq.Error) error {
+	switch err.Code {
+	case "23505":
+		return ErrUniqueViolation
+	case "23503":
+		return ErrForeignKeyViolation
+	case "23502":
+		return ErrNotNullViolation
+	case "23514":
+		return ErrCheckViolation
+	case "40P01":
+		return ErrDeadlock
+	case "40001":
+		return ErrSerializationFailure
+	case "55P03":
+		return ErrLockTimeout
+	case "42601":
+		return ErrSyntax
+	case "57P01", "57P02", "57P03":
+		// admin_shutdown, crash_shutdown, cannot_connect_now
+		return ErrConnectionLost
+	}
+
+	if err.Code.Class() == "08" {
+		return ErrConnectionLost
+	}
+	return nil
+}
+
+func classifyMySQL(err *mysql.MySQLError) error {
+	switch err.Number {
+	case 1062, 1586:
+		return ErrUniqueViolation
+	case 1216, 1217, 1451, 1452:
+		return ErrForeignKeyViolation
+	case 1048:
+		return ErrNotNullViolation
+	case 3819:
+		return ErrCheckViolation
+	case 1213:
+		return ErrDeadlock
+	case 1205:
+		return ErrLockTimeout
+	case 1064, 1149:
+		return ErrSyntax
+	case 1053, 1927, 2006, 2013:
+		return ErrConnectionLost
+	}
+	return nil
+}
+
+func classifySQLite(err sqlite3.Error) error {
+	switch err.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return ErrUniqueViolation
+	case sqlite3.ErrConstraintForeignKey:
+		return ErrForeignKeyViolation
+	case sqlite3.ErrConstraintNotNull:
+		return ErrNotNullViolation
+	case sqlite3.ErrConstraintCheck:
+		return ErrCheckViolation
+	case sqlite3.ErrBusySnapshot:
+		return ErrSerializationFailure
+	}
+
+	switch err.Code {
+	case sqlite3.ErrBusy, sqlite3.ErrLocked:
+		return ErrLockTimeout
+	case sqlite3.ErrError:
+		// SQLite has no dedicated code for syntax errors.
+		if strings.Contains(err.Error(), "syntax error") {
+			return ErrSyntax
+		}
+	}
+	return nil
+}
+
+// Hook classifies the errors returned by the driver.
+type Hook struct{}
+
+// New returns a new Hook.
+func New() *Hook {
+	return &Hook{}
+}
+
+func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	return ctx, nil
+}
+
+// OnError returns err wrapped in an *Error if it can be classified,
+// otherwise err is returned unchanged.
+func (h *Hook) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	if class := Classify(err); class != nil {
+		return &Error{Class: class, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package errclass
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	for _, it := range []struct {
+		err  error
+		want error
+	}{
+		{&pq.Error{Code: "23505"}, ErrUniqueViolation},
+		{&pq.Error{Code: "23503"}, ErrForeignKeyViolation},
+		{&pq.Error{Code: "40P01"}, ErrDeadlock},
+		{&pq.Error{Code: "40001"}, ErrSerializationFailure},
+		{&pq.Error{Code: "55P03"}, ErrLockTimeout},
+		{&pq.Error{Code: "08006"}, ErrConnectionLost},
+		{&pq.Error{Code: "42601"}, ErrSyntax},
+		{&pq.Error{Code: "22012"}, nil},
+		{&mysql.MySQLError{Number: 1062}, ErrUniqueViolation},
+		{&mysql.MySQLError{Number: 1452}, ErrForeignKeyViolation},
+		{&mysql.MySQLError{Number: 1213}, ErrDeadlock},
+		{&mysql.MySQLError{Number: 1205}, ErrLockTimeout},
+		{&mysql.MySQLError{Number: 1064}, ErrSyntax},
+		{&mysql.MySQLError{Number: 1146}, nil},
+		{sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}, ErrUniqueViolation},
+		{sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey}, ErrForeignKeyViolation},
+		{sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrBusySnapshot}, ErrSerializationFailure},
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, ErrLockTimeout},
+		{driver.ErrBadConn, ErrConnectionLost},
+		{mysql.ErrInvalidConn, ErrConnectionLost},
+		{fmt.Errorf("wrapped: %w", &pq.Error{Code: "23505"}), ErrUniqueViolation},
+		{errors.New("unknown"), nil},
+		{nil, nil},
+	} {
+		t.Run(fmt.Sprintf("%T %v", it.err, it.err), func(t *testing.T) {
+			assert.Equal(t, it.want, Classify(it.err))
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(&pq.Error{Code: "40P01"}))
+	assert.True(t, IsTransient(&mysql.MySQLError{Number: 1205}))
+	assert.True(t, IsTransient(fmt.Errorf("wrapped: %w", sqlite3.Error{Code: sqlite3.ErrBusy})))
+	assert.False(t, IsTransient(&pq.Error{Code: "23505"}))
+	assert.False(t, IsTransient(driver.ErrBadConn))
+	assert.False(t, IsTransient(nil))
+}
+
+func TestHook(t *testing.T) {
+	db := sqltest.Open(t, New())
+
+	_, err := db.Exec("CREATE TABLE users(id int PRIMARY KEY, name text NOT NULL)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users(id, name) VALUES(?, ?)", 1, "gus")
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO users(id, name) VALUES(?, ?)", 1, "gus")
+	assert.True(t, errors.Is(err, ErrUniqueViolation), "%v is not a unique violation", err)
+
+	var sqliteErr sqlite3.Error
+	require.True(t, errors.As(err, &sqliteErr), "driver error is not reachable")
+	assert.Equal(t, sqlite3.ErrConstraintPrimaryKey, sqliteErr.ExtendedCode)
+
+	_, err = db.Exec("INSERT INTO users(id, name) VALUES(?, NULL)", 2)
+	assert.True(t, errors.Is(err, ErrNotNullViolation), "%v is not a not null violation", err)
+
+	_, err = db.Exec("SELEC 1")
+	assert.True(t, errors.Is(err, ErrSyntax), "%v is not a syntax error", err)
+}
@@ -0,0 +1,261 @@
+// Package firewall provides a hook only letting through the statements of an
+// allowlist, identified by their fingerprint.
+//
+// Allowlists are text files holding a statement per line, its fingerprint
+// followed by a tab and the normalized statement, which is informative.
+// Backslashes, tabs and line breaks in statements are escaped as \\, \t, \n
+// and \r. Empty lines and lines starting with # are ignored:
+//
+//	# users
+//	281469707030c9a5	select * from users where id = ?
+//
+// In learning mode, unknown statements are recorded instead of rejected, and
+// can be written to a file to be reviewed.
+package firewall
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/qustavo/sqlhooks/v2/fingerprint"
+)
+
+// Mode tells what a Hook does with unknown statements.
+type Mode int32
+
+const (
+	// Enforcing mode rejects unknown statements.
+	Enforcing Mode = iota
+	// Learning mode records unknown statements and lets them through.
+	Learning
+)
+
+// ErrNotAllowed is matched by the errors of the statements missing from the
+// allowlist in enforcing mode, whose *Error holds the fingerprint to add.
+var ErrNotAllowed = errors.New("statement not allowed")
+
+// Error is returned by the statements rejected in enforcing mode.
+type Error struct {
+	Fingerprint fingerprint.Fingerprint
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("firewall: %s: %s %q", ErrNotAllowed, e.Fingerprint, e.Fingerprint.Query)
+}
+
+// Is matches ErrNotAllowed, whatever the fingerprint of the statement.
+func (e *Error) Is(target error) bool { return target == ErrNotAllowed }
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithMode sets the mode of the Hook, it defaults to Enforcing.
+func WithMode(mode Mode) Option {
+	return func(h *Hook) {
+		h.mode.Store(int32(mode))
+	}
+}
+
+// WithDialect sets the quoting rules used to fingerprint statements.
+func WithDialect(dialect fingerprint.Dialect) Option {
+	return func(h *Hook) {
+		h.dialect = dialect
+	}
+}
+
+type Hook struct {
+	dialect fingerprint.Dialect
+	mode    atomic.Int32
+
+	mu      sync.RWMutex
+	allowed map[uint64]string
+	learned map[uint64]string
+}
+
+// New returns a Hook with an empty allowlist.
+func New(opts ...Option) *Hook {
+	h := &Hook{
+		allowed: make(map[uint64]string),
+		learned: make(map[uint64]string),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Open returns a Hook with the allowlist stored at path. In learning mode, a
+// missing file is treated as an empty allowlist.
+func Open(path string, opts ...Option) (*Hook, error) {
+	h := New(opts...)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && h.Mode() == Learning {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := h.Read(f); err != nil {
+		return nil, fmt.Errorf("firewall: reading %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// SetMode switches the mode of the Hook. It's safe to call concurrently with
+// queries.
+func (h *Hook) SetMode(mode Mode) {
+	h.mode.Store(int32(mode))
+}
+
+// Mode returns the mode of the Hook.
+func (h *Hook) Mode() Mode {
+	return Mode(h.mode.Load())
+}
+
+// Allow adds query to the allowlist.
+func (h *Hook) Allow(query string) {
+	f := fingerprint.Of(h.dialect, query)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.allowed[f.Hash] = f.Query
+	delete(h.learned, f.Hash)
+}
+
+// Learned returns the statements recorded in learning mode, sorted by
+// query.
+func (h *Hook) Learned() []fingerprint.Fingerprint {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return sorted(h.learned)
+}
+
+func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	f := fingerprint.Of(h.dialect, query)
+
+	h.mu.RLock()
+	_, ok := h.allowed[f.Hash]
+	h.mu.RUnlock()
+	if ok {
+		return ctx, nil
+	}
+
+	if h.Mode() == Enforcing {
+		return ctx, &Error{Fingerprint: f}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.learned[f.Hash] = f.Query
+	return ctx, nil
+}
+
+func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	return ctx, nil
+}
+
+// Read adds the statements of the allowlist read from r.
+func (h *Hook) Read(r io.Reader) error {
+	entries := make(map[uint64]string)
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, query, _ := strings.Cut(line, "\t")
+		sum, err := strconv.ParseUint(hash, 16, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid fingerprint %q", n, hash)
+		}
+		entries[sum] = unescape.Replace(query)
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sum, query := range entries {
+		h.allowed[sum] = query
+		delete(h.learned, sum)
+	}
+	return nil
+}
+
+// Write writes the allowlist, including the learned statements, to w.
+func (h *Hook) Write(w io.Writer) error {
+	h.mu.RLock()
+	all := make(map[uint64]string, len(h.allowed)+len(h.learned))
+	for sum, query := range h.allowed {
+		all[sum] = query
+	}
+	for sum, query := range h.learned {
+		all[sum] = query
+	}
+	h.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range sorted(all) {
+		fmt.Fprintf(bw, "%s\t%s\n", f, escape.Replace(f.Query))
+	}
+	return bw.Flush()
+}
+
+// escape and unescape keep the statements of an allowlist on their line.
+var (
+	escape   = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+	unescape = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+)
+
+// WriteFile writes the allowlist, including the learned statements, to
+// path, with mode 0644. The file is replaced atomically.
+func (h *Hook) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// CreateTemp makes the file only readable by its owner
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := h.Write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func sorted(entries map[uint64]string) []fingerprint.Fingerprint {
+	list := make([]fingerprint.Fingerprint, 0, len(entries))
+	for sum, query := range entries {
+		list = append(list, fingerprint.Fingerprint{Query: query, Hash: sum})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Query != list[j].Query {
+			return list[i].Query < list[j].Query
+		}
+		return list[i].Hash < list[j].Hash
+	})
+	return list
+}
@@ -0,0 +1,122 @@
+package firewall
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2/fingerprint"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAllowlist(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "allowlist")
+	content := fmt.Sprintf("# users\n%s\tcreate table users(id int)\n\n%s\tselect * from users where id = ?\n",
+		fingerprint.Of(0, "CREATE TABLE users(id int)"),
+		fingerprint.Of(0, "SELECT * FROM users WHERE id = 1"),
+	)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestEnforcing(t *testing.T) {
+	hook, err := Open(writeAllowlist(t))
+	require.NoError(t, err)
+	db := sqltest.Open(t, hook)
+
+	_, err = db.Exec("create TABLE users (id INT)")
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM users WHERE id = ?", 42)
+	require.NoError(t, err)
+
+	_, err = db.Exec("DELETE FROM users WHERE id = 1")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+	var fErr *Error
+	require.True(t, errors.As(err, &fErr))
+	assert.Equal(t, "delete from users where id = ?", fErr.Fingerprint.Query)
+	assert.Equal(t, fmt.Sprintf("firewall: statement not allowed: %s \"delete from users where id = ?\"", fErr.Fingerprint), err.Error())
+	assert.Empty(t, hook.Learned())
+
+	hook.Allow("DELETE FROM users WHERE id = 2")
+	_, err = db.Exec("DELETE FROM users WHERE id = 1")
+	require.NoError(t, err)
+}
+
+func TestLearning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist")
+	hook, err := Open(path, WithMode(Learning))
+	require.NoError(t, err, "missing files are empty allowlists in learning mode")
+	db := sqltest.Open(t, hook)
+
+	_, err = db.Exec("CREATE TABLE users(id int)")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = db.Exec("INSERT INTO users VALUES (?)", i)
+		require.NoError(t, err)
+	}
+
+	learned := hook.Learned()
+	require.Len(t, learned, 2)
+	assert.Equal(t, "create table users(id int)", learned[0].Query)
+	assert.Equal(t, "insert into users values(?)", learned[1].Query)
+
+	require.NoError(t, hook.WriteFile(path))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s\tcreate table users(id int)\n%s\tinsert into users values(?)\n", learned[0], learned[1]), string(content))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	// the learned set is enforced once reloaded
+	enforcing, err := Open(path)
+	require.NoError(t, err)
+	db = sqltest.Open(t, enforcing)
+	_, err = db.Exec("CREATE TABLE users(id int)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users VALUES (?)", 1)
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM users")
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+
+	enforcing.SetMode(Learning)
+	_, err = db.Exec("SELECT * FROM users")
+	require.NoError(t, err)
+	assert.Len(t, enforcing.Learned(), 1)
+}
+
+func TestWriteEscapes(t *testing.T) {
+	hook := New()
+	hook.Allow("SELECT \"a\tb\" FROM \"c\r\nd\\e\"")
+
+	var b bytes.Buffer
+	require.NoError(t, hook.Write(&b))
+	written := b.String()
+	assert.Equal(t, 1, strings.Count(written, "\n"))
+	assert.Equal(t, 1, strings.Count(written, "\t"))
+	assert.Contains(t, written, `select "a\tb" from "c\r\nd\\e"`)
+
+	// reading the allowlist back restores the statements
+	reloaded := New()
+	require.NoError(t, reloaded.Read(&b))
+	require.NoError(t, reloaded.Write(&b))
+	assert.Equal(t, written, b.String())
+}
+
+func TestOpenErrors(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "missing"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	path := filepath.Join(t.TempDir(), "allowlist")
+	require.NoError(t, os.WriteFile(path, []byte("# comment\nnot-a-hash\tselect 1\n"), 0o644))
+	_, err = Open(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `line 2: invalid fingerprint "not-a-hash"`)
+}
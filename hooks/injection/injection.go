@@ -0,0 +1,284 @@
+// Package injection provides a hook flagging statements that look like the
+// result of SQL injection, for code building statements by concatenating
+// strings.
+//
+// The heuristics are:
+//
+//   - Tautology: OR comparing equal literals, i.e: OR '1'='1', or a string
+//     literal holding one, like the value x' OR '1'='1 once quoted.
+//   - StackedQueries: several statements in a single query.
+//   - CommentInLiteral: a string literal holding a comment, i.e: 'admin--'.
+//   - UnstableFingerprint: a call site running more distinct statements than
+//     the limit set with WithMaxFingerprints, even when their literals are
+//     ignored.
+//
+// They're heuristics, legitimate statements may be flagged, so findings are
+// reported to a callback, and only rejected if WithBlocking is used.
+package injection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/qustavo/sqlhooks/v2/fingerprint"
+	"github.com/qustavo/sqlhooks/v2/internal/callsite"
+	"github.com/qustavo/sqlhooks/v2/internal/sqlscan"
+)
+
+// Kind is the kind of a Finding.
+type Kind int
+
+const (
+	Tautology Kind = iota + 1
+	StackedQueries
+	CommentInLiteral
+	UnstableFingerprint
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Tautology:
+		return "tautology"
+	case StackedQueries:
+		return "stacked queries"
+	case CommentInLiteral:
+		return "comment in literal"
+	case UnstableFingerprint:
+		return "unstable fingerprint"
+	}
+	return "unknown"
+}
+
+// Finding is a statement flagged by the Hook.
+type Finding struct {
+	Kind  Kind
+	Query string
+	// Detail is the part of Query that was flagged.
+	Detail string
+	// CallSite is the file:line location that ran the query, and Stack the
+	// stack of the caller, outside of database/sql, sqlhooks and the
+	// packages given to WithSkipPackages.
+	CallSite string
+	Stack    string
+}
+
+// ErrInjection is matched by the errors of the statements blocked in blocking
+// mode, whichever heuristic flagged them.
+var ErrInjection = errors.New("injection: possible SQL injection")
+
+// Error is returned by the flagged statements in blocking mode.
+type Error struct {
+	Finding Finding
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%s): %s", ErrInjection, e.Finding.Kind, e.Finding.Detail)
+}
+
+// Is matches ErrInjection, so that callers can check for blocked statements
+// without looking at the Finding.
+func (e *Error) Is(target error) bool { return target == ErrInjection }
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithReporter sets the function findings are reported to.
+func WithReporter(fn func(ctx context.Context, f Finding)) Option {
+	return func(h *Hook) {
+		h.report = fn
+	}
+}
+
+// WithBlocking makes flagged statements fail with an *Error.
+func WithBlocking() Option {
+	return func(h *Hook) {
+		h.blocking = true
+	}
+}
+
+// WithDialect sets the quoting rules of the statements.
+func WithDialect(dialect fingerprint.Dialect) Option {
+	return func(h *Hook) {
+		h.dialect = dialect
+	}
+}
+
+// WithMaxFingerprints sets the number of distinct statements a call site may
+// run before being reported, it defaults to 10. 0 disables the heuristic.
+func WithMaxFingerprints(n int) Option {
+	return func(h *Hook) {
+		h.maxFingerprints = n
+	}
+}
+
+// WithSkipPackages skips the functions of the packages with the given import
+// path prefixes when looking for the caller, i.e: a query builder.
+func WithSkipPackages(prefixes ...string) Option {
+	return func(h *Hook) {
+		h.skip = append(h.skip, prefixes...)
+	}
+}
+
+type Hook struct {
+	report          func(ctx context.Context, f Finding)
+	blocking        bool
+	dialect         fingerprint.Dialect
+	maxFingerprints int
+	skip            callsite.Skip
+
+	mu    sync.Mutex
+	sites map[string]*site
+}
+
+// site holds the fingerprints of the statements run by a call site.
+type site struct {
+	hashes   map[uint64]struct{}
+	reported bool
+}
+
+func New(opts ...Option) *Hook {
+	h := &Hook{
+		maxFingerprints: 10,
+		skip:            callsite.New("github.com/qustavo/sqlhooks/v2/hooks/injection.(*Hook)"),
+		sites:           make(map[string]*site),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	var buf [64]uintptr
+	pcs := buf[:runtime.Callers(2, buf[:])]
+
+	findings := h.inspect(query)
+	if h.maxFingerprints > 0 {
+		if callSite := h.skip.Caller(pcs); callSite != "" && h.unstable(callSite, query) {
+			findings = append(findings, Finding{
+				Kind:   UnstableFingerprint,
+				Query:  query,
+				Detail: fmt.Sprintf("more than %d distinct statements run from %s", h.maxFingerprints, callSite),
+			})
+		}
+	}
+	if len(findings) == 0 {
+		return ctx, nil
+	}
+
+	callSite, stack := h.skip.Stack(pcs)
+	for i := range findings {
+		findings[i].CallSite, findings[i].Stack = callSite, stack
+		if h.report != nil {
+			h.report(ctx, findings[i])
+		}
+	}
+	if h.blocking {
+		return ctx, &Error{Finding: findings[0]}
+	}
+	return ctx, nil
+}
+
+func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	return ctx, nil
+}
+
+// tautology matches a quote closed early followed by OR or AND comparing two
+// values, capturing them.
+var tautology = regexp.MustCompile(`(?i)['"]\s*(?:or|and)\s+['"]?(\w+)['"]?\s*=\s*['"]?(\w+)`)
+
+// inspect returns the findings of the heuristics applied to the statement
+// alone.
+func (h *Hook) inspect(query string) []Finding {
+	var (
+		findings []Finding
+		tokens   []sqlscan.Token
+	)
+	for _, tok := range sqlscan.Scan(sqlscan.Dialect(h.dialect), query) {
+		if tok.Kind != sqlscan.Space && tok.Kind != sqlscan.Comment {
+			tokens = append(tokens, tok)
+		}
+	}
+	add := func(kind Kind, detail string) {
+		for _, f := range findings {
+			if f.Kind == kind {
+				return
+			}
+		}
+		findings = append(findings, Finding{Kind: kind, Query: query, Detail: detail})
+	}
+
+	for i, tok := range tokens {
+		switch {
+		case tok.Kind == sqlscan.String:
+			s := unquote(tok.Text)
+			if m := tautology.FindStringSubmatch(s); m != nil && strings.EqualFold(m[1], m[2]) {
+				add(Tautology, tok.Text)
+			}
+			if strings.Contains(s, "--") || strings.Contains(s, "/*") || strings.Contains(s, "*/") ||
+				h.dialect == fingerprint.MySQL && strings.Contains(s, "#") {
+				add(CommentInLiteral, tok.Text)
+			}
+		case tok.Kind == sqlscan.Word && strings.EqualFold(tok.Text, "or") && i+3 < len(tokens):
+			left, op, right := tokens[i+1], tokens[i+2], tokens[i+3]
+			if literal(left) && op.Text == "=" && literal(right) && unquote(left.Text) == unquote(right.Text) {
+				add(Tautology, fmt.Sprintf("%s %s%s%s", tok.Text, left.Text, op.Text, right.Text))
+			}
+		case tok.Text == ";" && i+1 < len(tokens):
+			add(StackedQueries, strings.TrimSpace(tokens[i+1].Text))
+		}
+	}
+	return findings
+}
+
+// unstable records the fingerprint of query for callSite, and reports
+// whether it's the first time the call site runs more distinct statements
+// than allowed.
+func (h *Hook) unstable(callSite, query string) bool {
+	f := fingerprint.Of(h.dialect, query)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.sites[callSite]
+	if !ok {
+		s = &site{hashes: make(map[uint64]struct{})}
+		h.sites[callSite] = s
+	}
+	if s.reported {
+		return false
+	}
+	s.hashes[f.Hash] = struct{}{}
+	if len(s.hashes) > h.maxFingerprints {
+		s.reported = true
+		s.hashes = nil
+		return true
+	}
+	return false
+}
+
+func literal(tok sqlscan.Token) bool {
+	return tok.Kind == sqlscan.String || tok.Kind == sqlscan.Number
+}
+
+// unquote returns the content of a string literal.
+func unquote(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	switch q := s[0]; q {
+	case '\'', '"':
+		s = strings.TrimSuffix(s[1:], string(q))
+		return strings.ReplaceAll(s, string(q)+string(q), string(q))
+	case 'E', 'e', 'X', 'x':
+		return unquote(s[1:])
+	case '$':
+		end := strings.IndexByte(s[1:], '$') + 2
+		return strings.TrimSuffix(s[end:], s[:end])
+	}
+	return s
+}
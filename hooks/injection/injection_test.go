@@ -0,0 +1,106 @@
+package injection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	h := New()
+	for _, it := range []struct {
+		query  string
+		kinds  []Kind
+		detail string
+	}{
+		{"SELECT * FROM users WHERE name = ?", nil, ""},
+		{"SELECT * FROM users WHERE 1=1 AND name = 'gus'", nil, ""},
+		{"SELECT * FROM users WHERE name = 'x' OR '1'='1'", []Kind{Tautology}, "OR '1'='1'"},
+		{"SELECT * FROM users WHERE id = 1 or 2 = 2", []Kind{Tautology}, "or 2=2"},
+		{"SELECT * FROM users WHERE name = 'x'' OR ''a''=''a'", []Kind{Tautology}, `'x'' OR ''a''=''a'`},
+		{"SELECT * FROM users WHERE name = 'x' OR 'a'='b'", nil, ""},
+		{"SELECT * FROM users WHERE name = 'admin''--'", []Kind{CommentInLiteral}, "'admin''--'"},
+		{"SELECT * FROM users WHERE name = 'a' -- trailing comment", nil, ""},
+		{"SELECT * FROM users WHERE id = 1; DROP TABLE users", []Kind{StackedQueries}, "DROP"},
+		{"SELECT * FROM users;", nil, ""},
+		{"SELECT * FROM users WHERE name = 'x' OR '1'='1'; DELETE FROM users --'", []Kind{Tautology, StackedQueries}, "OR '1'='1'"},
+	} {
+		findings := h.inspect(it.query)
+		var kinds []Kind
+		for _, f := range findings {
+			kinds = append(kinds, f.Kind)
+			assert.Equal(t, it.query, f.Query)
+		}
+		assert.Equal(t, it.kinds, kinds, it.query)
+		if len(findings) > 0 {
+			assert.Equal(t, it.detail, findings[0].Detail, it.query)
+		}
+	}
+}
+
+func TestReporter(t *testing.T) {
+	var findings []Finding
+	db := sqltest.Open(t, New(WithReporter(func(ctx context.Context, f Finding) {
+		findings = append(findings, f)
+	})))
+
+	_, err := db.Exec("CREATE TABLE users(name text)")
+	require.NoError(t, err)
+	name := "x' OR '1'='1"
+	rows, err := db.Query("SELECT * FROM users WHERE name = '" + name + "'")
+	require.NoError(t, err, "findings are only reported")
+	rows.Close()
+
+	require.Len(t, findings, 1)
+	f := findings[0]
+	assert.Equal(t, Tautology, f.Kind)
+	assert.True(t, strings.HasPrefix(filepath.Base(f.CallSite), "injection_test.go:"), f.CallSite)
+	assert.True(t, strings.HasPrefix(f.Stack, "github.com/qustavo/sqlhooks/v2/hooks/injection.TestReporter\n"), f.Stack)
+	assert.Contains(t, f.Stack, "\t"+f.CallSite+"\n")
+}
+
+func TestBlocking(t *testing.T) {
+	db := sqltest.Open(t, New(WithBlocking()))
+
+	_, err := db.Exec("SELECT 1; SELECT 2")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInjection))
+	var iErr *Error
+	require.True(t, errors.As(err, &iErr))
+	assert.Equal(t, StackedQueries, iErr.Finding.Kind)
+	assert.Equal(t, "injection: possible SQL injection (stacked queries): SELECT", err.Error())
+
+	_, err = db.Exec("SELECT ?", 1)
+	require.NoError(t, err)
+}
+
+func TestUnstableFingerprint(t *testing.T) {
+	var findings []Finding
+	db := sqltest.Open(t, New(WithMaxFingerprints(3), WithReporter(func(ctx context.Context, f Finding) {
+		findings = append(findings, f)
+	})))
+
+	// the literals change but the statement doesn't
+	for i := 0; i < 5; i++ {
+		_, err := db.Exec(fmt.Sprintf("SELECT %d", i))
+		require.NoError(t, err)
+	}
+	assert.Empty(t, findings)
+
+	columns := []string{"1", "1, 2", "1, 2, 3", "1, 2, 3, 4", "1, 2, 3, 4, 5"}
+	for _, c := range columns {
+		_, err := db.Exec("SELECT " + c)
+		require.NoError(t, err)
+	}
+	require.Len(t, findings, 1, "call sites are reported once")
+	assert.Equal(t, UnstableFingerprint, findings[0].Kind)
+	assert.Equal(t, "SELECT 1, 2, 3, 4", findings[0].Query)
+	assert.Contains(t, findings[0].Detail, "more than 3 distinct statements run from ")
+}
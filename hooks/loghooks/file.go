@@ -0,0 +1,210 @@
+package loghooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupFormat is the timestamp appended to the name of rotated files.
+const backupFormat = "20060102T150405.000000000"
+
+// RotateOptions define when a FileSink rotates its file, and how many rotated
+// files are kept. Zero values disable the corresponding limit.
+type RotateOptions struct {
+	// MaxSize is the size in bytes a file may grow to before being rotated.
+	MaxSize int64
+	// MaxAge is how long a file may be written to before being rotated.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files kept.
+	MaxBackups int
+	// Retention is how long rotated files are kept.
+	Retention time.Duration
+}
+
+// FileSink is an io.WriteCloser that appends to a file, rotating it
+// according to its RotateOptions. Rotated files are renamed by appending
+// the rotation time to their name. It is safe for concurrent use, and every
+// Write is written entirely to the same file, so it can be given to
+// WithJSON, or to log.New for WithLogger.
+type FileSink struct {
+	path string
+	opts RotateOptions
+	now  func() time.Time
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	opened  time.Time
+	rotated time.Time
+}
+
+// OpenFile opens path for appending, creating it if needed.
+func OpenFile(path string, opts RotateOptions) (*FileSink, error) {
+	s := &FileSink{path: path, opts: opts, now: time.Now}
+	if err := s.open(path); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	s.file, s.size, s.opened = f, info.Size(), s.now()
+	return nil
+}
+
+// Write appends p to the file, rotating it first if writing p would exceed
+// MaxSize or the file is older than MaxAge. If the rotation fails p is
+// appended to the current file anyway, and the rotation error is returned;
+// it's attempted again by the next Write.
+func (s *FileSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	var rotateErr error
+	if s.mustRotate(int64(len(p))) {
+		rotateErr = s.rotate()
+	}
+
+	n, err := s.file.Write(p)
+	s.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+func (s *FileSink) mustRotate(n int64) bool {
+	if s.size == 0 {
+		return false
+	}
+	if s.opts.MaxSize > 0 && s.size+n > s.opts.MaxSize {
+		return true
+	}
+	return s.opts.MaxAge > 0 && s.now().Sub(s.opened) >= s.opts.MaxAge
+}
+
+// Rotate closes the current file, renames it and opens a new one.
+func (s *FileSink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	return s.rotate()
+}
+
+func (s *FileSink) rotate() error {
+	// Backups are named after the rotation time, make sure they're unique.
+	rotated := s.now().UTC()
+	if !rotated.After(s.rotated) {
+		rotated = s.rotated.Add(time.Nanosecond)
+	}
+	s.rotated = rotated
+
+	backup := s.path + "." + rotated.Format(backupFormat)
+	current := s.path
+	err := s.file.Close()
+	if err == nil {
+		if err = os.Rename(s.path, backup); err == nil {
+			current = backup
+			err = s.open(s.path)
+		}
+	}
+	if err != nil {
+		// Go on appending to the file that could not be rotated, rather
+		// than losing every entry from now on. If it can't be reopened
+		// either, s.file stays closed and the next Write tries again.
+		if openErr := s.open(current); openErr != nil {
+			return errors.Join(err, openErr)
+		}
+		return err
+	}
+	return s.prune()
+}
+
+// prune removes the rotated files exceeding MaxBackups or Retention.
+func (s *FileSink) prune() error {
+	if s.opts.MaxBackups <= 0 && s.opts.Retention <= 0 {
+		return nil
+	}
+
+	backups, err := s.backups()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, b := range backups {
+		expired := false
+		if s.opts.MaxBackups > 0 && i < len(backups)-s.opts.MaxBackups {
+			expired = true
+		}
+		if s.opts.Retention > 0 && s.now().Sub(b.rotated) > s.opts.Retention {
+			expired = true
+		}
+		if expired {
+			if err := os.Remove(b.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type backup struct {
+	path    string
+	rotated time.Time
+}
+
+// backups returns the rotated files, oldest first.
+func (s *FileSink) backups() ([]backup, error) {
+	matches, err := filepath.Glob(s.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, m := range matches {
+		t, err := time.Parse(backupFormat, strings.TrimPrefix(m, s.path+"."))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: m, rotated: t})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotated.Before(backups[j].rotated) })
+	return backups, nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
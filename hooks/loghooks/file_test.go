@@ -0,0 +1,189 @@
+package loghooks
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readLines(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func openTestFile(t *testing.T, opts RotateOptions) (*FileSink, *clock, string) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	c := &clock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	s, err := OpenFile(path, opts)
+	require.NoError(t, err)
+	s.now = c.Now
+	s.opened = c.Now()
+	t.Cleanup(func() { s.Close() })
+	return s, c, path
+}
+
+func TestFileSinkRotateSize(t *testing.T) {
+	s, c, path := openTestFile(t, RotateOptions{MaxSize: 10})
+
+	for _, line := range []string{"12345\n", "6789\n", "abc\n"} {
+		_, err := s.Write([]byte(line))
+		require.NoError(t, err)
+		c.Add(time.Second)
+	}
+
+	backups, err := s.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, []string{"12345"}, readLines(t, backups[0].path))
+	assert.Equal(t, []string{"6789", "abc"}, readLines(t, path))
+}
+
+func TestFileSinkRotateFailure(t *testing.T) {
+	s, c, path := openTestFile(t, RotateOptions{MaxSize: 10})
+
+	_, err := s.Write([]byte("12345\n"))
+	require.NoError(t, err)
+	c.Add(time.Second)
+
+	// A directory in the way of the backup makes the rename fail.
+	blocked := path + "." + c.Now().Format(backupFormat)
+	require.NoError(t, os.MkdirAll(filepath.Join(blocked, "dir"), 0o755))
+	n, err := s.Write([]byte("6789\n"))
+	assert.Error(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, []string{"12345", "6789"}, readLines(t, path))
+
+	require.NoError(t, os.RemoveAll(blocked))
+	c.Add(time.Second)
+	_, err = s.Write([]byte("abc\n"))
+	require.NoError(t, err)
+
+	backups, err := s.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, []string{"12345", "6789"}, readLines(t, backups[0].path))
+	assert.Equal(t, []string{"abc"}, readLines(t, path))
+}
+
+func TestFileSinkRotateAge(t *testing.T) {
+	s, c, path := openTestFile(t, RotateOptions{MaxAge: time.Hour})
+
+	_, err := s.Write([]byte("old\n"))
+	require.NoError(t, err)
+	c.Add(30 * time.Minute)
+	_, err = s.Write([]byte("still old\n"))
+	require.NoError(t, err)
+	c.Add(30 * time.Minute)
+	_, err = s.Write([]byte("new\n"))
+	require.NoError(t, err)
+
+	backups, err := s.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, []string{"old", "still old"}, readLines(t, backups[0].path))
+	assert.Equal(t, []string{"new"}, readLines(t, path))
+}
+
+func TestFileSinkRetention(t *testing.T) {
+	t.Run("MaxBackups", func(t *testing.T) {
+		s, c, _ := openTestFile(t, RotateOptions{MaxBackups: 2})
+		for i := 0; i < 5; i++ {
+			_, err := s.Write([]byte("line\n"))
+			require.NoError(t, err)
+			c.Add(time.Minute)
+			require.NoError(t, s.Rotate())
+		}
+
+		backups, err := s.backups()
+		require.NoError(t, err)
+		require.Len(t, backups, 2)
+		assert.Equal(t, c.Now().Add(-time.Minute), backups[0].rotated)
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		s, c, _ := openTestFile(t, RotateOptions{Retention: 90 * time.Minute})
+		for i := 0; i < 4; i++ {
+			_, err := s.Write([]byte("line\n"))
+			require.NoError(t, err)
+			c.Add(time.Hour)
+			require.NoError(t, s.Rotate())
+		}
+
+		backups, err := s.backups()
+		require.NoError(t, err)
+		require.Len(t, backups, 2)
+	})
+}
+
+func TestFileSinkConcurrentWrites(t *testing.T) {
+	s, _, path := openTestFile(t, RotateOptions{MaxSize: 256, MaxBackups: 1000})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := s.Write([]byte("0123456789\n"))
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, s.Close())
+
+	backups, err := s.backups()
+	require.NoError(t, err)
+
+	var lines []string
+	for _, b := range backups {
+		lines = append(lines, readLines(t, b.path)...)
+	}
+	lines = append(lines, readLines(t, path)...)
+	require.Len(t, lines, 1000)
+	for _, l := range lines {
+		assert.Equal(t, "0123456789", l)
+	}
+}
+
+func TestFileSinkClosed(t *testing.T) {
+	s, _, _ := openTestFile(t, RotateOptions{})
+	require.NoError(t, s.Close())
+
+	_, err := s.Write([]byte("line\n"))
+	assert.True(t, errors.Is(err, os.ErrClosed), "unexpected error: %v", err)
+}
@@ -0,0 +1,99 @@
+package loghooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// WithJSON makes the Hook write entries to w as JSON Lines, one object per
+// query with the following fields: time, query, sql, args, duration (in
+// nanoseconds), error, instance, labels and plan. Empty fields are omitted.
+// Every entry is written with a single call to w.Write, and calls are
+// serialized, so w needs not be safe for concurrent use.
+func WithJSON(w io.Writer) Option {
+	return func(h *Hook) {
+		h.json = &jsonWriter{w: w}
+		h.slog = nil
+	}
+}
+
+type jsonEntry struct {
+	Time     time.Time         `json:"time"`
+	Query    string            `json:"query"`
+	SQL      string            `json:"sql,omitempty"`
+	Args     []interface{}     `json:"args"`
+	Duration time.Duration     `json:"duration"`
+	Error    string            `json:"error,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Plan     string            `json:"plan,omitempty"`
+}
+
+type jsonWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (j *jsonWriter) write(e *Entry) error {
+	entry := jsonEntry{
+		Time:     e.Time,
+		Query:    e.Query,
+		SQL:      e.Rendered,
+		Args:     jsonArgs(e.Args),
+		Duration: e.Duration,
+		Plan:     e.Plan,
+	}
+	if e.Err != nil {
+		entry.Error = e.Err.Error()
+	}
+	if e.Instance != nil {
+		entry.Instance = e.Instance.Name
+		entry.Labels = e.Instance.Labels
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return j.writeLine(line)
+}
+
+// writeLine writes line followed by a new line with a single call to Write.
+func (j *jsonWriter) writeLine(line []byte) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.buf.Reset()
+	j.buf.Write(line)
+	j.buf.WriteByte('\n')
+	_, err := j.w.Write(j.buf.Bytes())
+	return err
+}
+
+// jsonArgs converts the arguments that can't be represented in JSON.
+func jsonArgs(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, v := range args {
+		switch v := v.(type) {
+		case []byte:
+			// Valid UTF-8 is logged as text, and base64 encoded otherwise.
+			if utf8.Valid(v) {
+				converted[i] = string(v)
+				continue
+			}
+		}
+
+		if _, err := json.Marshal(v); err != nil {
+			converted[i] = fmt.Sprint(v)
+			continue
+		}
+		converted[i] = v
+	}
+	return converted
+}
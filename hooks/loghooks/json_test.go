@@ -0,0 +1,113 @@
+package loghooks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	db := sqltest.Open(t, New(WithJSON(buf)),
+		sqlhooks.WithInstance("audit", map[string]string{"role": "primary"}),
+	)
+
+	_, err := db.Exec("SELECT ?, ?, ?", "gus", []byte{0xff}, nil)
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var e map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e), scanner.Text())
+		entries = append(entries, e)
+	}
+	require.Len(t, entries, 2)
+
+	for _, e := range entries {
+		ts, err := time.Parse(time.RFC3339Nano, e["time"].(string))
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), ts, time.Minute)
+		assert.IsType(t, float64(0), e["duration"])
+		assert.Equal(t, "audit", e["instance"])
+		assert.Equal(t, map[string]interface{}{"role": "primary"}, e["labels"])
+	}
+
+	assert.Equal(t, "SELECT ?, ?, ?", entries[0]["query"])
+	assert.Equal(t, []interface{}{"gus", "/w==", nil}, entries[0]["args"])
+	assert.NotContains(t, entries[0], "error")
+
+	assert.Equal(t, "SELECT * FROM missing", entries[1]["query"])
+	assert.Equal(t, []interface{}{}, entries[1]["args"])
+	assert.Equal(t, "no such table: missing", entries[1]["error"])
+}
+
+func TestJSONArgs(t *testing.T) {
+	assert.Equal(t,
+		[]interface{}{"text", []byte{0xff}, "(1+2i)", int64(1)},
+		jsonArgs([]interface{}{[]byte("text"), []byte{0xff}, complex(1, 2), int64(1)}),
+	)
+}
+
+// chunkWriter records every call to Write separately, and is not safe for
+// concurrent use on purpose.
+type chunkWriter struct {
+	chunks [][]byte
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.chunks = append(w.chunks, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func TestJSONConcurrentWrites(t *testing.T) {
+	w := &chunkWriter{}
+	db := sqltest.Open(t, New(WithJSON(w)))
+	db.SetMaxOpenConns(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, err := db.Exec("SELECT ?", fmt.Sprintf("%d-%d", i, j))
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, w.chunks, 400)
+	for _, c := range w.chunks {
+		assert.True(t, json.Valid(c), "%s", c)
+		assert.Equal(t, byte('\n'), c[len(c)-1])
+	}
+}
+
+func TestJSONToFile(t *testing.T) {
+	path := t.TempDir() + "/audit.jsonl"
+	sink, err := OpenFile(path, RotateOptions{MaxSize: 1 << 20})
+	require.NoError(t, err)
+
+	db := sqltest.Open(t, New(WithJSON(sink)))
+
+	_, err = db.Exec("SELECT 1")
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+
+	lines := readLines(t, path)
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"query":"SELECT 1"`)
+}
@@ -2,37 +2,250 @@ package loghooks
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
+	"text/template"
 	"time"
+
+	"github.com/qustavo/sqlhooks/v2"
 )
 
 var started int
 
-type logger interface {
+// Logger is the interface used to write log lines, *log.Logger satisfies it.
+type Logger interface {
 	Printf(string, ...interface{})
 }
 
+// Entry holds the details of a logged query, it's the data format templates
+// are executed with.
+type Entry struct {
+	// Time is when the query finished.
+	Time     time.Time
+	Query    string
+	Args     []interface{}
+	Duration time.Duration
+	// Err is nil for successful queries.
+	Err error
+	// Instance is nil unless the driver was wrapped with sqlhooks.WithInstance.
+	Instance *sqlhooks.Instance
+	// Plan is the execution plan of slow queries, see WithExplain.
+	Plan string
+	// Rendered is the query with the arguments substituted, see
+	// WithRenderedSQL.
+	Rendered string
+}
+
+const (
+	// DefaultFormat is the template used to log successful queries.
+	DefaultFormat = "{{with .Instance}}Instance: {{.}}, {{end}}" +
+		"{{if .Rendered}}Query: `{{.Rendered}}`{{else}}Query: `{{.Query}}`, Args: `{{quote .Args}}`{{end}}. took: {{.Duration}}" +
+		"{{with .Plan}}\nPlan:\n{{.}}{{end}}"
+	// DefaultErrorFormat is the template used to log failed queries.
+	DefaultErrorFormat = "{{with .Instance}}Instance: {{.}}, {{end}}Error: {{.Err}}, " +
+		"{{if .Rendered}}Query: `{{.Rendered}}`{{else}}Query: `{{.Query}}`, Args: `{{quote .Args}}`{{end}}, Took: {{.Duration}}"
+)
+
+var (
+	funcs = template.FuncMap{
+		"quote": func(v interface{}) string { return fmt.Sprintf("%q", v) },
+	}
+	defaultSuccess = template.Must(template.New("success").Funcs(funcs).Parse(DefaultFormat))
+	defaultFailure = template.Must(template.New("failure").Funcs(funcs).Parse(DefaultErrorFormat))
+)
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithLogger sets the Logger lines are written to. It defaults to a
+// *log.Logger writing to os.Stderr.
+func WithLogger(l Logger) Option {
+	return func(h *Hook) {
+		h.log = l
+		h.slog = nil
+		h.json = nil
+	}
+}
+
+// WithSlog makes the Hook write structured records to l, with the query,
+// args, duration and error as attributes. Unless a template is set using
+// WithFormat, the record message is "query" or "query failed".
+func WithSlog(l *slog.Logger) Option {
+	return func(h *Hook) {
+		h.slog = l
+		h.json = nil
+	}
+}
+
+// WithLevels sets the slog levels of successful and failed queries. They
+// default to slog.LevelInfo and slog.LevelError. Levels only apply to the
+// records written using WithSlog: a Logger or WithJSON output has no levels,
+// and gets every entry regardless of them.
+func WithLevels(success, failure slog.Level) Option {
+	return func(h *Hook) {
+		h.successLevel = success
+		h.errorLevel = failure
+	}
+}
+
+// WithFormat sets the text/template used to format successful and failed
+// queries. Templates are executed with an Entry, and may use the quote
+// function to format a value with %q. Empty strings keep the current
+// template. It panics if a template can't be parsed.
+func WithFormat(success, failure string) Option {
+	return func(h *Hook) {
+		if success != "" {
+			h.success = template.Must(template.New("success").Funcs(funcs).Parse(success))
+		}
+		if failure != "" {
+			h.failure = template.Must(template.New("failure").Funcs(funcs).Parse(failure))
+		}
+	}
+}
+
 type Hook struct {
-	log logger
+	log          Logger
+	slog         *slog.Logger
+	json         *jsonWriter
+	successLevel slog.Level
+	errorLevel   slog.Level
+	success      *template.Template
+	failure      *template.Template
+
+	slow           time.Duration
+	slowOps        map[string]time.Duration
+	explainDB      *sql.DB
+	explain        string
+	explainTimeout time.Duration
+
+	dialect  Dialect
+	throttle *throttle
+
+	redactors  []Redactor
+	maxArgSize int
 }
 
-func New() *Hook {
-	return &Hook{
-		log: log.New(os.Stderr, "", log.LstdFlags),
+func New(opts ...Option) *Hook {
+	h := &Hook{
+		log:          log.New(os.Stderr, "", log.LstdFlags),
+		successLevel: slog.LevelInfo,
+		errorLevel:   slog.LevelError,
+		success:      defaultSuccess,
+		failure:      defaultFailure,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
+
 func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
 	return context.WithValue(ctx, &started, time.Now()), nil
 }
 
 func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
-	h.log.Printf("Query: `%s`, Args: `%q`. took: %s", query, args, time.Since(ctx.Value(&started).(time.Time)))
+	e := newEntry(ctx, nil, query, args)
+	slow := h.slow > 0 || h.slowOps != nil
+	if slow && !h.isSlow(e) {
+		return ctx, nil
+	}
+	if !h.allow(ctx, e) {
+		return ctx, nil
+	}
+	if slow && h.explainDB != nil {
+		e.Plan = h.plan(ctx, query, args)
+	}
+
+	h.write(ctx, e)
 	return ctx, nil
 }
 
 func (h *Hook) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
-	h.log.Printf("Error: %v, Query: `%s`, Args: `%q`, Took: %s",
-		err, query, args, time.Since(ctx.Value(&started).(time.Time)))
+	if e := newEntry(ctx, err, query, args); h.allow(ctx, e) {
+		h.write(ctx, e)
+	}
 	return err
 }
+
+func newEntry(ctx context.Context, err error, query string, args []interface{}) *Entry {
+	e := &Entry{Time: time.Now(), Query: query, Args: args, Err: err}
+	if t, ok := ctx.Value(&started).(time.Time); ok {
+		e.Duration = time.Since(t)
+	}
+	if i, ok := sqlhooks.InstanceFromContext(ctx); ok {
+		e.Instance = &i
+	}
+	return e
+}
+
+func (h *Hook) write(ctx context.Context, e *Entry) {
+	names := sqlhooks.ArgNames(ctx)
+	e.Args = h.redact(e.Query, e.Args, names)
+	if h.dialect != 0 {
+		e.Rendered = Render(h.dialect, e.Query, e.Args, names)
+	}
+
+	switch {
+	case h.json != nil:
+		if err := h.json.write(e); err != nil {
+			h.log.Printf("loghooks: writing JSON entry: %v", err)
+		}
+		return
+	case h.slog != nil:
+		h.writeSlog(ctx, e)
+		return
+	}
+
+	tmpl := h.success
+	if e.Err != nil {
+		tmpl = h.failure
+	}
+	h.log.Printf("%s", render(tmpl, e))
+}
+
+func (h *Hook) writeSlog(ctx context.Context, e *Entry) {
+	level, tmpl, msg := h.successLevel, h.success, "query"
+	if e.Err != nil {
+		level, tmpl, msg = h.errorLevel, h.failure, "query failed"
+	}
+	if !h.slog.Enabled(ctx, level) {
+		return
+	}
+	if tmpl != defaultSuccess && tmpl != defaultFailure {
+		msg = render(tmpl, e)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("query", e.Query),
+		slog.Any("args", e.Args),
+		slog.Duration("duration", e.Duration),
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.Any("error", e.Err))
+	}
+	if e.Rendered != "" {
+		attrs = append(attrs, slog.String("sql", e.Rendered))
+	}
+	if e.Plan != "" {
+		attrs = append(attrs, slog.String("plan", e.Plan))
+	}
+	if e.Instance != nil {
+		attrs = append(attrs, slog.String("instance", e.Instance.Name))
+		if len(e.Instance.Labels) > 0 {
+			attrs = append(attrs, slog.Any("labels", e.Instance.Labels))
+		}
+	}
+	h.slog.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func render(tmpl *template.Template, e *Entry) string {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, e); err != nil {
+		return fmt.Sprintf("loghooks: formatting %q: %v", e.Query, err)
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package loghooks
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bufLogger struct {
+	bytes.Buffer
+}
+
+func (l *bufLogger) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(&l.Buffer, format+"\n", args...)
+}
+
+var took = regexp.MustCompile(`(took|Took): [0-9.]+[a-zµ]+`)
+
+// String returns the buffer with durations replaced by a constant.
+func (l *bufLogger) String() string {
+	return took.ReplaceAllString(l.Buffer.String(), "$1: 1ms")
+}
+
+func TestLogger(t *testing.T) {
+	buf := &bufLogger{}
+	db := sqltest.Open(t, New(WithLogger(buf)))
+
+	_, err := db.Exec("SELECT ?", "gus")
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+
+	assert.Equal(t, "Query: `SELECT ?`, Args: `[\"gus\"]`. took: 1ms\n"+
+		"Error: no such table: missing, Query: `SELECT * FROM missing`, Args: `[]`, Took: 1ms\n",
+		buf.String())
+}
+
+func TestFormat(t *testing.T) {
+	buf := &bufLogger{}
+	db := sqltest.Open(t, New(
+		WithLogger(buf),
+		WithFormat("ok {{.Query}} {{len .Args}}", "ko {{.Query}}: {{.Err}}"),
+	))
+
+	_, err := db.Exec("SELECT ?, ?", 1, 2)
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+
+	assert.Equal(t, "ok SELECT ?, ? 2\nko SELECT * FROM missing: no such table: missing\n", buf.String())
+}
+
+func TestInstance(t *testing.T) {
+	buf := &bufLogger{}
+	db := sqltest.Open(t, New(WithLogger(buf)),
+		sqlhooks.WithInstance("analytics", map[string]string{"role": "replica", "db.system": "sqlite"}),
+	)
+
+	_, err := db.Exec("SELECT 1")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Instance: analytics{db.system=sqlite,role=replica}, Query: `SELECT 1`")
+
+	buf.Reset()
+	_, err = db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+	assert.Contains(t, buf.String(), "Instance: analytics{db.system=sqlite,role=replica}, Error: no such table: missing")
+}
+
+func newSlog(buf *bytes.Buffer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.Attr{}
+			case "duration":
+				return slog.String("duration", "1ms")
+			}
+			return a
+		},
+	}))
+}
+
+func TestSlog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	db := sqltest.Open(t, New(WithSlog(newSlog(buf, slog.LevelDebug))),
+		sqlhooks.WithInstance("primary", nil),
+	)
+
+	_, err := db.Exec("SELECT ?", "gus")
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+
+	assert.Equal(t, `level=INFO msg=query query="SELECT ?" args=[gus] duration=1ms instance=primary`+"\n"+
+		`level=ERROR msg="query failed" query="SELECT * FROM missing" args=[] duration=1ms error="no such table: missing" instance=primary`+"\n",
+		buf.String())
+}
+
+func TestSlogLevels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	db := sqltest.Open(t, New(
+		WithSlog(newSlog(buf, slog.LevelInfo)),
+		WithLevels(slog.LevelDebug, slog.LevelWarn),
+		WithFormat("", "{{.Err}}"),
+	))
+
+	_, err := db.Exec("SELECT 1")
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+
+	assert.Equal(t, `level=WARN msg="no such table: missing" query="SELECT * FROM missing" args=[] duration=1ms error="no such table: missing"`+"\n",
+		buf.String())
+}
@@ -0,0 +1,269 @@
+package loghooks
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqlscan"
+)
+
+// Redacted replaces the value of redacted arguments.
+const Redacted = "<redacted>"
+
+// Redactor returns the value to log for the argument at index i of query.
+// name is the argument name if it was passed using sql.Named, and column the
+// column it's compared to or inserted in, if it could be found.
+type Redactor func(query string, i int, name, column string, value interface{}) interface{}
+
+// WithRedactAll hides the value of every argument.
+func WithRedactAll() Option {
+	return WithRedactor(func(string, int, string, string, interface{}) interface{} {
+		return Redacted
+	})
+}
+
+// WithRedactPositions hides the arguments at the given positions, starting
+// at 1 like database/sql/driver.NamedValue ordinals.
+func WithRedactPositions(positions ...int) Option {
+	redact := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		redact[p-1] = true
+	}
+
+	return WithRedactor(func(_ string, i int, _, _ string, v interface{}) interface{} {
+		if redact[i] {
+			return Redacted
+		}
+		return v
+	})
+}
+
+// WithRedactNames hides the arguments passed with sql.Named using any of the
+// given names.
+func WithRedactNames(names ...string) Option {
+	redact := make(map[string]bool, len(names))
+	for _, n := range names {
+		redact[n] = true
+	}
+
+	return WithRedactor(func(_ string, _ int, name, _ string, v interface{}) interface{} {
+		if name != "" && redact[name] {
+			return Redacted
+		}
+		return v
+	})
+}
+
+// WithRedactColumns hides the arguments whose column matches re. The column
+// of an argument is found in INSERT column lists and comparisons such as
+// `password = ?` or `token IN (?, ?)`.
+func WithRedactColumns(re *regexp.Regexp) Option {
+	return WithRedactor(func(_ string, _ int, _, column string, v interface{}) interface{} {
+		if column != "" && re.MatchString(column) {
+			return Redacted
+		}
+		return v
+	})
+}
+
+// WithRedactor adds a Redactor, redactors are applied in the order they are
+// given.
+func WithRedactor(r Redactor) Option {
+	return func(h *Hook) {
+		h.redactors = append(h.redactors, r)
+	}
+}
+
+// WithMaxArgSize truncates string and []byte arguments longer than n bytes,
+// appending a marker with the number of bytes removed.
+func WithMaxArgSize(n int) Option {
+	return func(h *Hook) {
+		h.maxArgSize = n
+	}
+}
+
+// redact returns a copy of args with the redactors and size limits applied.
+func (h *Hook) redact(query string, args []interface{}, names []string) []interface{} {
+	if len(args) == 0 || (len(h.redactors) == 0 && h.maxArgSize <= 0) {
+		return args
+	}
+
+	var cols []string
+	if len(h.redactors) > 0 {
+		cols = columns(h.dialect, query, names, len(args))
+	}
+
+	redacted := make([]interface{}, len(args))
+	for i, v := range args {
+		var name, col string
+		if i < len(names) {
+			name = names[i]
+		}
+		if i < len(cols) {
+			col = cols[i]
+		}
+
+		for _, r := range h.redactors {
+			v = r(query, i, name, col, v)
+		}
+		redacted[i] = truncate(v, h.maxArgSize)
+	}
+	return redacted
+}
+
+func truncate(v interface{}, n int) interface{} {
+	if n <= 0 {
+		return v
+	}
+
+	switch v := v.(type) {
+	case string:
+		if len(v) > n {
+			// don't split a multi-byte character
+			for n > 0 && !utf8.RuneStart(v[n]) {
+				n--
+			}
+			return v[:n] + marker(len(v)-n)
+		}
+	case []byte:
+		if len(v) > n {
+			return append(v[:n:n], marker(len(v)-n)...)
+		}
+	}
+	return v
+}
+
+func marker(n int) string {
+	return fmt.Sprintf("...(%d bytes truncated)", n)
+}
+
+// columns returns the column of each of the nargs arguments of query, or an
+// empty string if it could not be found.
+func columns(dialect Dialect, query string, names []string, nargs int) []string {
+	var (
+		cols   = make([]string, nargs)
+		tokens = significant(tokenize(dialect, query, names))
+		// insert are the columns of an INSERT statement, once VALUES has
+		// been found.
+		insert []string
+		values bool
+		// pos is the position within the current VALUES tuple.
+		pos   int
+		depth int
+	)
+
+	set := func(arg int, col string) {
+		if arg >= 0 && arg < nargs && cols[arg] == "" {
+			cols[arg] = col
+		}
+	}
+
+	for i, tok := range tokens {
+		switch {
+		case tok.Kind == sqlscan.Word && strings.EqualFold(tok.Name, "INTO"):
+			insert = columnList(tokens[i+1:])
+		case tok.Kind == sqlscan.Word && strings.EqualFold(tok.Name, "VALUES"):
+			values, depth = insert != nil, 0
+		case values && tok.Text == "(":
+			depth++
+			if depth == 1 {
+				pos = 0
+			}
+		case values && tok.Text == ")":
+			depth--
+		case values && tok.Text == "," && depth == 1:
+			pos++
+		case tok.Kind == sqlscan.Placeholder:
+			if values && depth == 1 && pos < len(insert) {
+				set(tok.arg, insert[pos])
+			} else {
+				set(tok.arg, compared(tokens[:i]))
+			}
+		}
+	}
+	return cols
+}
+
+// significant filters out space and comment tokens.
+func significant(tokens []token) []token {
+	filtered := tokens[:0:0]
+	for _, tok := range tokens {
+		if tok.Kind != sqlscan.Space && tok.Kind != sqlscan.Comment {
+			filtered = append(filtered, tok)
+		}
+	}
+	return filtered
+}
+
+// columnList parses `table (a, b, c)` returning the column names.
+func columnList(tokens []token) []string {
+	// skip the (maybe qualified) table name
+	i := 0
+	for i < len(tokens) && (tokens[i].Kind == sqlscan.Word || tokens[i].Text == ".") {
+		i++
+	}
+	if i == 0 || i >= len(tokens) || tokens[i].Text != "(" {
+		return nil
+	}
+
+	var cols []string
+	for i++; i < len(tokens); i += 2 {
+		if tokens[i].Kind != sqlscan.Word {
+			return nil
+		}
+		cols = append(cols, tokens[i].Name)
+		if i+1 < len(tokens) && tokens[i+1].Text == ")" {
+			return cols
+		}
+		if i+1 >= len(tokens) || tokens[i+1].Text != "," {
+			return nil
+		}
+	}
+	return nil
+}
+
+// compared returns the column compared to a placeholder following tokens,
+// as in `col = ?`, `col LIKE ?` or `col IN (?, ?)`.
+func compared(tokens []token) string {
+	i := len(tokens) - 1
+
+	// IN lists
+	for i >= 0 && (tokens[i].Text == "," || tokens[i].Kind == sqlscan.Placeholder) {
+		i--
+	}
+	if i >= 0 && tokens[i].Text == "(" && i > 0 && tokens[i-1].Kind == sqlscan.Word && strings.EqualFold(tokens[i-1].Name, "IN") {
+		i -= 2
+	} else {
+		i = len(tokens) - 1
+		if i < 0 || !isComparison(tokens[i]) {
+			return ""
+		}
+		i--
+	}
+
+	if i >= 0 && tokens[i].Kind == sqlscan.Word && strings.EqualFold(tokens[i].Name, "NOT") {
+		i--
+	}
+	if i >= 0 && tokens[i].Kind == sqlscan.Word {
+		return tokens[i].Name
+	}
+	return ""
+}
+
+func isComparison(tok token) bool {
+	switch tok.Kind {
+	case sqlscan.Other:
+		switch tok.Text {
+		case "=", "<>", "!=", "<", ">", "<=", ">=":
+			return true
+		}
+	case sqlscan.Word:
+		switch strings.ToUpper(tok.Name) {
+		case "LIKE", "ILIKE", "GLOB":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package loghooks
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumns(t *testing.T) {
+	for _, it := range []struct {
+		query string
+		nargs int
+		want  []string
+	}{
+		{"INSERT INTO users (id, `password`) VALUES (?, ?), (?, ?)", 4, []string{"id", "password", "id", "password"}},
+		{`INSERT INTO "public"."users"("id", "token") VALUES ($1, lower($2))`, 2, []string{"id", ""}},
+		{"UPDATE users SET password = ?, name=? WHERE u.id <> ?", 3, []string{"password", "name", "id"}},
+		{"SELECT * FROM users WHERE token NOT IN (?, ?) AND name LIKE ?", 3, []string{"token", "token", "name"}},
+		{"SELECT * FROM users WHERE id = :id -- password = ?", 1, []string{"id"}},
+		{"SELECT ?", 1, []string{""}},
+	} {
+		t.Run(it.query, func(t *testing.T) {
+			assert.Equal(t, it.want, columns(0, it.query, nil, it.nargs))
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	const query = "INSERT INTO users(id, name, password) VALUES(?, ?, ?)"
+	args := []interface{}{int64(1), "gus", "hunter2"}
+
+	for _, it := range []struct {
+		name string
+		opts []Option
+		want []interface{}
+	}{
+		{"none", nil, args},
+		{"all", []Option{WithRedactAll()}, []interface{}{Redacted, Redacted, Redacted}},
+		{"positions", []Option{WithRedactPositions(1, 3)}, []interface{}{Redacted, "gus", Redacted}},
+		{"columns", []Option{WithRedactColumns(regexp.MustCompile(`(?i)pass|token`))}, []interface{}{int64(1), "gus", Redacted}},
+		{"redactor", []Option{WithRedactor(func(_ string, i int, _, col string, v interface{}) interface{} {
+			if col == "name" {
+				return strings.ToUpper(v.(string))
+			}
+			return v
+		})}, []interface{}{int64(1), "GUS", "hunter2"}},
+		{"truncate", []Option{WithMaxArgSize(3)}, []interface{}{int64(1), "gus", "hun...(4 bytes truncated)"}},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			h := New(it.opts...)
+			assert.Equal(t, it.want, h.redact(query, args, nil))
+		})
+	}
+	assert.Equal(t, "hunter2", args[2], "args were modified")
+}
+
+func TestRedactNames(t *testing.T) {
+	buf := &bufLogger{}
+	db := sqltest.Open(t, New(WithLogger(buf), WithRenderedSQL(SQLite), WithRedactNames("secret")))
+
+	_, err := db.Exec("SELECT :id, :secret", sql.Named("id", int64(1)), sql.Named("secret", "s3cr3t"))
+	require.NoError(t, err)
+	assert.Equal(t, "Query: `SELECT 1, '<redacted>'`. took: 1ms\n", buf.String())
+}
+
+func TestTruncateBytes(t *testing.T) {
+	blob := []byte("0123456789")
+	assert.Equal(t, []byte("0123...(6 bytes truncated)"), truncate(blob, 4))
+	assert.Equal(t, []byte("0123456789"), blob)
+}
+
+func TestTruncateRunes(t *testing.T) {
+	assert.Equal(t, "ñand...(2 bytes truncated)", truncate("ñandú", 6))
+	assert.Equal(t, "...(2 bytes truncated)", truncate("ñ", 1))
+}
@@ -0,0 +1,178 @@
+package loghooks
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqlscan"
+)
+
+// Dialect defines the placeholders and quoting rules used by Render. The zero
+// Dialect accepts the placeholders of every dialect.
+type Dialect int
+
+const (
+	// MySQL uses ? placeholders.
+	MySQL Dialect = iota + 1
+	// Postgres uses $N placeholders.
+	Postgres
+	// SQLite uses ?, ?N, :name, @name and $name placeholders.
+	SQLite
+)
+
+// WithRenderedSQL makes the Hook log a runnable rendering of the statements,
+// with the arguments substituted for the placeholders of dialect.
+func WithRenderedSQL(dialect Dialect) Option {
+	return func(h *Hook) {
+		h.dialect = dialect
+	}
+}
+
+// Render substitutes args for the placeholders of query. names are the names
+// of args, as returned by sqlhooks.ArgNames, it may be nil. Placeholders
+// inside literals, quoted identifiers and comments are left untouched, as are
+// the ones without a matching argument.
+func Render(dialect Dialect, query string, args []interface{}, names []string) string {
+	var b strings.Builder
+	for _, tok := range tokenize(dialect, query, names) {
+		if tok.arg >= 0 && tok.arg < len(args) {
+			b.WriteString(literal(dialect, args[tok.arg]))
+			continue
+		}
+		b.WriteString(tok.Text)
+	}
+	return b.String()
+}
+
+// token is a token of a statement, arg is the index of the argument bound to
+// placeholders, -1 for other tokens.
+type token struct {
+	sqlscan.Token
+	arg int
+}
+
+// tokenize splits query into tokens, binding its placeholders to args, whose
+// names are names.
+func tokenize(dialect Dialect, query string, names []string) []token {
+	b := binder{dialect: dialect, names: names}
+	scanned := sqlscan.Scan(sqlscan.Dialect(dialect), query)
+	tokens := make([]token, len(scanned))
+	for i, tok := range scanned {
+		tokens[i] = token{Token: tok, arg: -1}
+		if tok.Kind == sqlscan.Placeholder {
+			tokens[i].arg = b.bind(tok.Text)
+		}
+	}
+	return tokens
+}
+
+// binder returns the index of the argument placeholders refer to.
+type binder struct {
+	dialect Dialect
+	names   []string
+
+	// next is the index of the last ? placeholder, and seen the indexes
+	// assigned to named placeholders, used to bind SQLite parameters.
+	next int
+	seen map[string]int
+}
+
+func (b *binder) bind(placeholder string) int {
+	c, rest := placeholder[0], placeholder[1:]
+	switch {
+	case c == '?' && (rest == "" || b.dialect == MySQL):
+		b.next++
+		return b.next - 1
+	case c == '?':
+		n, _ := strconv.Atoi(rest)
+		if n > b.next {
+			b.next = n
+		}
+		return n - 1
+	case c == '$' && b.dialect != SQLite && strings.Trim(rest, "0123456789") == "":
+		n, _ := strconv.Atoi(rest)
+		return n - 1
+	}
+	return b.named(rest)
+}
+
+// named returns the index of the argument bound to the name placeholder.
+// Named arguments are matched by name, otherwise SQLite assigns the next
+// index to every distinct name.
+func (b *binder) named(name string) int {
+	for i, n := range b.names {
+		if n == name {
+			return i
+		}
+	}
+
+	if idx, ok := b.seen[name]; ok {
+		return idx
+	}
+	if b.seen == nil {
+		b.seen = make(map[string]int)
+	}
+	b.next++
+	b.seen[name] = b.next - 1
+	return b.next - 1
+}
+
+func literal(dialect Dialect, v interface{}) string {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil {
+			v = value
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quote(dialect, v)
+	case []byte:
+		if v == nil {
+			return "NULL"
+		}
+		if dialect == Postgres {
+			return `'\x` + hex.EncodeToString(v) + `'`
+		}
+		return "X'" + hex.EncodeToString(v) + "'"
+	case bool:
+		if dialect == SQLite {
+			if v {
+				return "1"
+			}
+			return "0"
+		}
+		return strings.ToUpper(strconv.FormatBool(v))
+	case time.Time:
+		switch dialect {
+		case MySQL:
+			return quote(dialect, v.Format("2006-01-02 15:04:05.999999"))
+		case Postgres:
+			return quote(dialect, v.Format("2006-01-02 15:04:05.999999Z07:00"))
+		default:
+			return quote(dialect, v.Format("2006-01-02 15:04:05.999999999-07:00"))
+		}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return quote(dialect, fmt.Sprint(v))
+	}
+}
+
+func quote(dialect Dialect, s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	if dialect == MySQL {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + s + "'"
+}
@@ -0,0 +1,70 @@
+package loghooks
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 800000000, time.UTC)
+	for _, it := range []struct {
+		name    string
+		dialect Dialect
+		query   string
+		args    []interface{}
+		names   []string
+		want    string
+	}{
+		{"mysql", MySQL, "SELECT * FROM t WHERE a = ? AND b = ? AND c = ?", []interface{}{"it's", int64(1), nil},
+			nil, "SELECT * FROM t WHERE a = 'it''s' AND b = 1 AND c = NULL"},
+		{"mysql backslash", MySQL, `SELECT ?, '\'?', ` + "`?`", []interface{}{`a\b`},
+			nil, `SELECT 'a\\b', '\'?', ` + "`?`"},
+		{"mysql comment", MySQL, "SELECT ? # what?\n", []interface{}{int64(1)},
+			nil, "SELECT 1 # what?\n"},
+		{"mysql types", MySQL, "INSERT INTO t VALUES(?, ?, ?, ?)", []interface{}{[]byte{0xde, 0xad}, ts, true, 1.5},
+			nil, "INSERT INTO t VALUES(X'dead', '2021-03-04 05:06:07.8', TRUE, 1.5)"},
+		{"postgres", Postgres, "SELECT $2::text, $1, '$1', $$ $1 $$, $tag$ $2 $tag$", []interface{}{int64(1), "a"},
+			nil, "SELECT 'a'::text, 1, '$1', $$ $1 $$, $tag$ $2 $tag$"},
+		{"postgres escape string", Postgres, `SELECT E'it\'s $1', $1`, []interface{}{"X"},
+			nil, `SELECT E'it\'s $1', 'X'`},
+		{"postgres types", Postgres, "INSERT INTO t VALUES($1, $2, $3, $4)", []interface{}{[]byte{0xde, 0xad}, ts, false, nil},
+			nil, `INSERT INTO t VALUES('\xdead', '2021-03-04 05:06:07.8Z', FALSE, NULL)`},
+		{"postgres missing arg", Postgres, "SELECT $1, $2", []interface{}{int64(1)},
+			nil, "SELECT 1, $2"},
+		{"sqlite positional", SQLite, "SELECT ?, ?2, ?", []interface{}{"a", "b", "c"},
+			nil, "SELECT 'a', 'b', 'c'"},
+		{"sqlite named by order", SQLite, "SELECT :a, @b, $c, :a", []interface{}{"a", "b", "c"},
+			nil, "SELECT 'a', 'b', 'c', 'a'"},
+		{"sqlite named", SQLite, "SELECT :b, :a", []interface{}{"a", "b"},
+			[]string{"a", "b"}, "SELECT 'b', 'a'"},
+		{"sqlite types", SQLite, "SELECT ?, ?, ?", []interface{}{[]byte{1}, true, ts},
+			nil, "SELECT X'01', 1, '2021-03-04 05:06:07.8+00:00'"},
+		{"comments", SQLite, "SELECT ? -- ?\n, ? /* ? */", []interface{}{int64(1), int64(2)},
+			nil, "SELECT 1 -- ?\n, 2 /* ? */"},
+		{"valuer", Postgres, "SELECT $1, $2", []interface{}{sql.NullString{}, sql.NullInt64{Int64: 3, Valid: true}},
+			nil, "SELECT NULL, 3"},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			assert.Equal(t, it.want, Render(it.dialect, it.query, it.args, it.names))
+		})
+	}
+}
+
+func TestRenderedSQL(t *testing.T) {
+	buf := &bufLogger{}
+	db := sqltest.Open(t, New(WithLogger(buf), WithRenderedSQL(SQLite)))
+
+	_, err := db.Exec("SELECT ?, :name", "it's", sql.Named("name", []byte("gus")))
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM missing WHERE id = ?", int64(1))
+	require.Error(t, err)
+
+	assert.Equal(t, "Query: `SELECT 'it''s', X'677573'`. took: 1ms\n"+
+		"Error: no such table: missing, Query: `SELECT * FROM missing WHERE id = 1`, Took: 1ms\n",
+		buf.String())
+}
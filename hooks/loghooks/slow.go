@@ -0,0 +1,139 @@
+package loghooks
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/classifier"
+	"github.com/qustavo/sqlhooks/v2/internal/sqlscan"
+)
+
+// ExplainQueryPlan is the explain prefix for SQLite, see WithExplain.
+const ExplainQueryPlan = "EXPLAIN QUERY PLAN"
+
+// WithSlowQuery makes the Hook log successful queries only if they take
+// longer than threshold. Failed queries are always logged.
+func WithSlowQuery(threshold time.Duration) Option {
+	return func(h *Hook) {
+		h.slow = threshold
+	}
+}
+
+// WithOperationThreshold sets the slow query threshold of the statements
+// starting with the op keyword, i.e: "SELECT" or "UPDATE", overriding the one
+// set by WithSlowQuery.
+func WithOperationThreshold(op string, threshold time.Duration) Option {
+	return func(h *Hook) {
+		if h.slowOps == nil {
+			h.slowOps = make(map[string]time.Duration)
+		}
+		h.slowOps[strings.ToUpper(op)] = threshold
+	}
+}
+
+// DefaultExplainTimeout is the time WithExplain waits for a plan when it's
+// given no timeout.
+const DefaultExplainTimeout = 100 * time.Millisecond
+
+// WithExplain makes the Hook run slow statements prefixed by explain, and
+// include the resulting plan in the log entry. It has no effect unless slow
+// query mode is enabled. If explain is empty "EXPLAIN"
+// is used, SQLite requires ExplainQueryPlan instead.
+// db must not be instrumented by the Hook, it's usually a separate *sql.DB
+// opened through the underlying driver. Plans are only requested for single
+// statements that classifier.Classify finds to only read, as some explains
+// run the statement they're given, like EXPLAIN ANALYZE on PostgreSQL.
+// The plan is requested before the statement returns, so it adds to its
+// latency: the request is abandoned after timeout, DefaultExplainTimeout if
+// it's not positive, or as soon as the context of the statement is done.
+// The statement is explained with the arguments it ran with, which are
+// given by name if it used sql.Named, but with no other driver specific
+// options they may have been passed with.
+func WithExplain(db *sql.DB, explain string, timeout time.Duration) Option {
+	if explain == "" {
+		explain = "EXPLAIN"
+	}
+	if timeout <= 0 {
+		timeout = DefaultExplainTimeout
+	}
+
+	return func(h *Hook) {
+		h.explainDB = db
+		h.explain = explain
+		h.explainTimeout = timeout
+	}
+}
+
+// operation returns the first keyword of query in upper case.
+func operation(query string) string {
+	return sqlscan.Keyword(sqlscan.Any, query)
+}
+
+// isSlow reports whether e must be logged in slow query mode.
+func (h *Hook) isSlow(e *Entry) bool {
+	threshold := h.slow
+	if t, ok := h.slowOps[operation(e.Query)]; ok {
+		threshold = t
+	}
+	return e.Duration >= threshold
+}
+
+// plan returns the execution plan of query, or a description of the reason
+// why it could not be obtained.
+func (h *Hook) plan(ctx context.Context, query string, args []interface{}) string {
+	if stmt := classifier.Classify(query); stmt.Multi || stmt.Kind != classifier.Select || stmt.IsWrite() {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.explainTimeout)
+	defer cancel()
+
+	if names := sqlhooks.ArgNames(ctx); names != nil {
+		named := make([]interface{}, len(args))
+		for i, arg := range args {
+			if names[i] != "" {
+				arg = sql.Named(names[i], arg)
+			}
+			named[i] = arg
+		}
+		args = named
+	}
+
+	rows, err := h.explainDB.QueryContext(ctx, h.explain+" "+query, args...)
+	if err != nil {
+		return "explain failed: " + err.Error()
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "explain failed: " + err.Error()
+	}
+
+	var (
+		lines  []string
+		values = make([]sql.NullString, len(cols))
+		dest   = make([]interface{}, len(cols))
+	)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "explain failed: " + err.Error()
+		}
+
+		fields := make([]string, len(values))
+		for i, v := range values {
+			fields[i] = v.String
+		}
+		lines = append(lines, strings.Join(fields, " "))
+	}
+	if err := rows.Err(); err != nil {
+		return "explain failed: " + err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,106 @@
+package loghooks
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperation(t *testing.T) {
+	for query, want := range map[string]string{
+		"SELECT 1":                             "SELECT",
+		"  select\n1":                          "SELECT",
+		"-- comment\nUPDATE t SET a = 1":       "UPDATE",
+		"/* c */ (SELECT 1) UNION (SELECT 2)":  "SELECT",
+		"with x as (select 1) select * from x": "WITH",
+		"/* unterminated":                      "",
+	} {
+		assert.Equal(t, want, operation(query), query)
+	}
+}
+
+func TestSlowQuery(t *testing.T) {
+	buf := &bufLogger{}
+	db := sqltest.Open(t, New(
+		WithLogger(buf),
+		WithSlowQuery(time.Hour),
+		WithOperationThreshold("insert", time.Nanosecond),
+	))
+
+	_, err := db.Exec("CREATE TABLE users(id int)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users(id) VALUES(?)", "1")
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM users")
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+
+	assert.Equal(t, "Query: `INSERT INTO users(id) VALUES(?)`, Args: `[\"1\"]`. took: 1ms\n"+
+		"Error: no such table: missing, Query: `SELECT * FROM missing`, Args: `[]`, Took: 1ms\n",
+		buf.String())
+}
+
+func TestExplain(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "explain.db")
+	explainDB, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	defer explainDB.Close()
+
+	buf := &bufLogger{}
+	hook := New(
+		WithLogger(buf),
+		WithSlowQuery(time.Nanosecond),
+		WithExplain(explainDB, ExplainQueryPlan, time.Second),
+	)
+	db := sqltest.OpenDSN(t, dsn, hook)
+
+	_, err = db.Exec("CREATE TABLE users(id int, name text)")
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "Plan:")
+
+	for _, query := range []string{
+		"INSERT INTO users(id) VALUES(1)",
+		"WITH x AS (SELECT 1) UPDATE users SET name = 'gus'",
+		"SELECT 1; DELETE FROM users",
+	} {
+		buf.Reset()
+		_, err = db.Exec(query)
+		require.NoError(t, err)
+		assert.NotContains(t, buf.String(), "Plan:", query)
+	}
+
+	buf.Reset()
+	_, err = db.Exec("SELECT name FROM users WHERE id = ?", "1")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Query: `SELECT name FROM users WHERE id = ?`, Args: `[\"1\"]`. took: 1ms\nPlan:\n")
+	assert.Contains(t, buf.String(), "users")
+
+	buf.Reset()
+	_, err = db.Exec("SELECT name FROM users WHERE id = :id", sql.Named("id", "1"))
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Plan:\n")
+	assert.NotContains(t, buf.String(), "explain failed")
+}
+
+func TestExplainTimeout(t *testing.T) {
+	assert.Equal(t, DefaultExplainTimeout, New(WithExplain(nil, "", 0)).explainTimeout)
+	assert.Equal(t, time.Second, New(WithExplain(nil, "", time.Second)).explainTimeout)
+}
+
+func TestExplainCanceled(t *testing.T) {
+	explainDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer explainDB.Close()
+
+	hook := New(WithExplain(explainDB, ExplainQueryPlan, time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, "explain failed: context canceled", hook.plan(ctx, "SELECT 1", nil))
+}
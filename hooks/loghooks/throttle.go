@@ -0,0 +1,210 @@
+package loghooks
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2/fingerprint"
+)
+
+// WithThrottle limits the log entries of similar queries to n per interval.
+// Queries are similar if they have the same fingerprint, which is logged in
+// summaries, see fingerprint.Normalize. Successful and failed queries are
+// throttled independently. Once an interval is over, a summary with the
+// number of suppressed entries is written for every throttled query, from a
+// timer if no other entry is logged by then. See also Hook.Flush.
+func WithThrottle(n int, interval time.Duration) Option {
+	return func(h *Hook) {
+		h.throttle = &throttle{limit: n, interval: interval, now: time.Now}
+		h.throttle.expired = func(summaries []summary) {
+			h.writeSummaries(context.Background(), summaries)
+		}
+	}
+}
+
+type throttleKey struct {
+	fingerprint string
+	failed      bool
+}
+
+// summary describes the entries suppressed during an interval.
+type summary struct {
+	throttleKey
+	suppressed int
+}
+
+type throttle struct {
+	limit    int
+	interval time.Duration
+	now      func() time.Time
+	// expired writes the summaries of an interval ended by timer.
+	expired func([]summary)
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[throttleKey]int
+	// timer ends the current interval, it's set once an entry is
+	// suppressed so that its summary is written even if no entry follows.
+	timer *time.Timer
+	// epoch counts the intervals, so that a timer that could not be stopped
+	// doesn't end the next one.
+	epoch int
+}
+
+// allow reports whether an entry for key may be written. If the current
+// interval is over, it also returns the summaries of the previous one.
+func (t *throttle) allow(key throttleKey) (bool, []summary) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var summaries []summary
+	if now := t.now(); now.Sub(t.start) >= t.interval {
+		summaries = t.flush()
+		t.start = now
+	}
+
+	if t.counts == nil {
+		t.counts = make(map[throttleKey]int)
+	}
+	t.counts[key]++
+	if t.counts[key] > t.limit && t.timer == nil {
+		epoch := t.epoch
+		t.timer = time.AfterFunc(t.start.Add(t.interval).Sub(t.now()), func() {
+			t.expire(epoch)
+		})
+	}
+	return t.counts[key] <= t.limit, summaries
+}
+
+// expire ends the interval epoch, unless it's already over.
+func (t *throttle) expire(epoch int) {
+	t.mu.Lock()
+	if t.epoch != epoch {
+		t.mu.Unlock()
+		return
+	}
+	summaries := t.flush()
+	t.start = t.now()
+	t.mu.Unlock()
+
+	t.expired(summaries)
+}
+
+// flush returns the summaries of the current interval and resets it.
+// t.mu must be held.
+func (t *throttle) flush() []summary {
+	var summaries []summary
+	for key, n := range t.counts {
+		if n > t.limit {
+			summaries = append(summaries, summary{key, n - t.limit})
+		}
+	}
+	t.counts = nil
+	t.epoch++
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].fingerprint != summaries[j].fingerprint {
+			return summaries[i].fingerprint < summaries[j].fingerprint
+		}
+		return !summaries[i].failed && summaries[j].failed
+	})
+	return summaries
+}
+
+// Flush writes the summaries of the queries suppressed in the current
+// throttling interval, and starts a new one. It's meant to be called before
+// exiting, so that no summary is lost.
+func (h *Hook) Flush() {
+	if h.throttle == nil {
+		return
+	}
+
+	h.throttle.mu.Lock()
+	summaries := h.throttle.flush()
+	h.throttle.start = h.throttle.now()
+	h.throttle.mu.Unlock()
+
+	h.writeSummaries(context.Background(), summaries)
+}
+
+// allow reports whether e may be written, writing the pending summaries.
+func (h *Hook) allow(ctx context.Context, e *Entry) bool {
+	if h.throttle == nil {
+		return true
+	}
+
+	f := fingerprint.Of(fingerprint.Dialect(h.dialect), e.Query)
+	ok, summaries := h.throttle.allow(throttleKey{f.Query, e.Err != nil})
+	h.writeSummaries(ctx, summaries)
+	return ok
+}
+
+func (h *Hook) writeSummaries(ctx context.Context, summaries []summary) {
+	for _, s := range summaries {
+		h.writeSummary(ctx, s)
+	}
+}
+
+func (s summary) message() string {
+	kind := "queries"
+	if s.failed {
+		kind = "failed queries"
+	}
+	return "suppressed " + thousands(s.suppressed) + " similar " + kind
+}
+
+func (h *Hook) writeSummary(ctx context.Context, s summary) {
+	switch {
+	case h.json != nil:
+		line, err := json.Marshal(struct {
+			Time        time.Time `json:"time"`
+			Fingerprint string    `json:"fingerprint"`
+			Failed      bool      `json:"failed"`
+			Suppressed  int       `json:"suppressed"`
+		}{time.Now(), s.fingerprint, s.failed, s.suppressed})
+		if err == nil {
+			err = h.json.writeLine(line)
+		}
+		if err != nil {
+			h.log.Printf("loghooks: writing JSON entry: %v", err)
+		}
+	case h.slog != nil:
+		level := h.successLevel
+		if s.failed {
+			level = h.errorLevel
+		}
+		h.slog.LogAttrs(ctx, level, s.message(),
+			slog.String("fingerprint", s.fingerprint),
+			slog.Int("suppressed", s.suppressed),
+		)
+	default:
+		h.log.Printf("%s: `%s`", s.message(), s.fingerprint)
+	}
+}
+
+// thousands formats n with comma separated thousands.
+func thousands(n int) string {
+	if n < 0 {
+		return "-" + thousands(-n)
+	}
+	s := strconv.Itoa(n)
+
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package loghooks
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThousands(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 999: "999", 1000: "1,000", 4211: "4,211", 1234567: "1,234,567", -1000: "-1,000"} {
+		assert.Equal(t, want, thousands(n))
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	buf := &bufLogger{}
+	hook := New(WithLogger(buf), WithThrottle(2, time.Minute))
+	c := &clock{now: time.Now()}
+	hook.throttle.now = c.Now
+	db := sqltest.Open(t, hook)
+
+	for i := 0; i < 5; i++ {
+		_, err := db.Exec(fmt.Sprintf("SELECT %d", i))
+		require.NoError(t, err)
+		_, err = db.Exec(fmt.Sprintf("SELECT * FROM missing WHERE id = %d", i))
+		require.Error(t, err)
+	}
+	assert.Equal(t, "Query: `SELECT 0`, Args: `[]`. took: 1ms\n"+
+		"Error: no such table: missing, Query: `SELECT * FROM missing WHERE id = 0`, Args: `[]`, Took: 1ms\n"+
+		"Query: `SELECT 1`, Args: `[]`. took: 1ms\n"+
+		"Error: no such table: missing, Query: `SELECT * FROM missing WHERE id = 1`, Args: `[]`, Took: 1ms\n",
+		buf.String())
+
+	buf.Reset()
+	c.Add(time.Minute)
+	_, err := db.Exec("SELECT 5")
+	require.NoError(t, err)
+	assert.Equal(t, "suppressed 3 similar failed queries: `select * from missing where id = ?`\n"+
+		"suppressed 3 similar queries: `select ?`\n"+
+		"Query: `SELECT 5`, Args: `[]`. took: 1ms\n",
+		buf.String())
+}
+
+func TestThrottleFlush(t *testing.T) {
+	buf := &bytes.Buffer{}
+	hook := New(WithSlog(newSlog(buf, slog.LevelInfo)), WithThrottle(1, time.Hour))
+	db := sqltest.Open(t, hook)
+
+	for i := 0; i < 1002; i++ {
+		_, err := db.Exec("SELECT 1")
+		require.NoError(t, err)
+	}
+
+	buf.Reset()
+	hook.Flush()
+	assert.Equal(t, `level=INFO msg="suppressed 1,001 similar queries" fingerprint="select ?" suppressed=1001`+"\n", buf.String())
+
+	buf.Reset()
+	hook.Flush()
+	assert.Empty(t, buf.String())
+}
+
+// chanLogger sends the lines it's given to a channel.
+type chanLogger chan string
+
+func (l chanLogger) Printf(format string, args ...interface{}) {
+	l <- fmt.Sprintf(format, args...)
+}
+
+func TestThrottleTimer(t *testing.T) {
+	lines := make(chanLogger, 10)
+	db := sqltest.Open(t, New(WithLogger(lines), WithThrottle(1, 50*time.Millisecond)))
+
+	for i := 0; i < 3; i++ {
+		_, err := db.Exec("SELECT 1")
+		require.NoError(t, err)
+	}
+	assert.Contains(t, <-lines, "Query: `SELECT 1`")
+
+	select {
+	case line := <-lines:
+		assert.Equal(t, "suppressed 2 similar queries: `select ?`", line)
+	case <-time.After(time.Second):
+		t.Fatal("the summary was not written")
+	}
+}
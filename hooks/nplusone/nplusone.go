@@ -0,0 +1,238 @@
+// Package nplusone detects N+1 query patterns: the same query run over and
+// over within a request, typically once per row returned by a previous one.
+//
+// Detection happens within scopes, started with NewScope, usually once per
+// request:
+//
+//	hook := nplusone.New(nplusone.WithReporter(func(ctx context.Context, p nplusone.Pattern) {
+//		log.Printf("N+1 query: %s, run %d times from %v", p.Query, p.Count, p.CallSites)
+//	}))
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		ctx := nplusone.NewScope(r.Context())
+//		...
+//	}
+package nplusone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/qustavo/sqlhooks/v2/fingerprint"
+	"github.com/qustavo/sqlhooks/v2/internal/callsite"
+)
+
+// Pattern is a query run repeatedly within a scope.
+type Pattern struct {
+	// Query is the normalized query.
+	Query string
+	// Count is the number of times the query was run within the scope.
+	Count int
+	// CallSites are the distinct file:line locations that ran the query,
+	// outside of database/sql, sqlhooks and the packages given to
+	// WithSkipPackages.
+	CallSites []string
+}
+
+// ErrNPlusOne is what strict mode failures match, for callers that don't
+// need the Pattern of the *Error.
+var ErrNPlusOne = errors.New("nplusone: N+1 query")
+
+// Error is returned by queries reaching the threshold in strict mode.
+type Error struct {
+	Pattern Pattern
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: run %d times in scope: %s", ErrNPlusOne, e.Pattern.Count, e.Pattern.Query)
+}
+
+// Is matches ErrNPlusOne, telling strict mode failures apart from the errors
+// of the driver.
+func (e *Error) Is(target error) bool { return target == ErrNPlusOne }
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithThreshold sets the number of times a query has to run within a scope
+// for it to be reported, it defaults to 10.
+func WithThreshold(n int) Option {
+	return func(h *Hook) {
+		h.threshold = n
+	}
+}
+
+// WithReporter sets the function patterns are reported to. It's called once
+// per pattern and scope, when the query reaches the threshold.
+func WithReporter(fn func(ctx context.Context, p Pattern)) Option {
+	return func(h *Hook) {
+		h.report = fn
+	}
+}
+
+// WithStrict makes queries reaching the threshold fail with an *Error, it's
+// meant to be used in tests.
+func WithStrict() Option {
+	return func(h *Hook) {
+		h.strict = true
+	}
+}
+
+// WithDialect sets the quoting rules used to fingerprint queries.
+func WithDialect(dialect fingerprint.Dialect) Option {
+	return func(h *Hook) {
+		h.dialect = dialect
+	}
+}
+
+// WithSkipPackages skips the functions of the packages with the given import
+// path prefixes when looking for call sites, i.e: an ORM.
+func WithSkipPackages(prefixes ...string) Option {
+	return func(h *Hook) {
+		h.skip = append(h.skip, prefixes...)
+	}
+}
+
+// maxCallSites bounds the number of call sites kept per pattern.
+const maxCallSites = 10
+
+type Hook struct {
+	threshold int
+	report    func(ctx context.Context, p Pattern)
+	strict    bool
+	dialect   fingerprint.Dialect
+	skip      callsite.Skip
+}
+
+func New(opts ...Option) *Hook {
+	h := &Hook{
+		threshold: 10,
+		skip:      callsite.New("github.com/qustavo/sqlhooks/v2/hooks/nplusone.(*Hook)"),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// scope holds the queries run within a scope.
+type scope struct {
+	mu       sync.Mutex
+	patterns map[uint64]*Pattern
+	reported map[uint64]bool
+}
+
+type scopeKey struct{}
+
+// countedKey holds the fingerprint hash of the query counted by Before.
+type countedKey struct{}
+
+// NewScope returns a copy of ctx starting a detection scope. Queries run
+// with contexts derived from it are counted together, replacing any parent
+// scope.
+func NewScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{
+		patterns: make(map[uint64]*Pattern),
+		reported: make(map[uint64]bool),
+	})
+}
+
+// Patterns returns the queries run at least twice within the scope of ctx,
+// regardless of the threshold. It's nil if ctx has no scope.
+func Patterns(ctx context.Context) []Pattern {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var patterns []Pattern
+	for _, p := range s.patterns {
+		if p.Count > 1 {
+			patterns = append(patterns, copyPattern(p))
+		}
+	}
+	return patterns
+}
+
+func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return ctx, nil
+	}
+
+	f := fingerprint.Of(h.dialect, query)
+	var pcs [32]uintptr
+	site := h.skip.Caller(pcs[:runtime.Callers(2, pcs[:])])
+
+	s.mu.Lock()
+	p, ok := s.patterns[f.Hash]
+	if !ok {
+		p = &Pattern{Query: f.Query}
+		s.patterns[f.Hash] = p
+	}
+	p.Count++
+	if site != "" && len(p.CallSites) < maxCallSites && !contains(p.CallSites, site) {
+		p.CallSites = append(p.CallSites, site)
+	}
+	count, pattern := p.Count, copyPattern(p)
+	report := count >= h.threshold && !s.reported[f.Hash]
+	if report {
+		s.reported[f.Hash] = true
+	}
+	s.mu.Unlock()
+
+	ctx = context.WithValue(ctx, countedKey{}, f.Hash)
+	if count < h.threshold {
+		return ctx, nil
+	}
+	if report && h.report != nil {
+		h.report(ctx, pattern)
+	}
+	if h.strict {
+		return ctx, &Error{Pattern: pattern}
+	}
+	return ctx, nil
+}
+
+func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	return ctx, nil
+}
+
+// OnSkip undoes the count of a statement the driver skipped, so that running
+// it again as a prepared statement isn't taken for a repetition.
+func (h *Hook) OnSkip(ctx context.Context, query string, args ...interface{}) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+	hash, ok := ctx.Value(countedKey{}).(uint64)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.patterns[hash]; ok {
+		p.Count--
+	}
+}
+
+func copyPattern(p *Pattern) Pattern {
+	c := *p
+	c.CallSites = append([]string(nil), p.CallSites...)
+	return c
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
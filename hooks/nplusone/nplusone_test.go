@@ -0,0 +1,114 @@
+package nplusone
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2/internal/skipdriver"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openDB(t *testing.T, hook *Hook) *sql.DB {
+	db := sqltest.Open(t, hook)
+	_, err := db.Exec("CREATE TABLE users(id int, name text)")
+	require.NoError(t, err)
+	return db
+}
+
+func loadUser(ctx context.Context, db *sql.DB, id int) error {
+	var name sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT name FROM users WHERE id = ?", id).Scan(&name)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+func TestReporter(t *testing.T) {
+	var reported []Pattern
+	hook := New(WithThreshold(3), WithReporter(func(ctx context.Context, p Pattern) {
+		reported = append(reported, p)
+	}))
+	db := openDB(t, hook)
+
+	// queries outside scopes are not counted
+	for i := 0; i < 5; i++ {
+		require.NoError(t, loadUser(context.Background(), db, i))
+	}
+	assert.Empty(t, reported)
+
+	ctx := NewScope(context.Background())
+	for i := 0; i < 5; i++ {
+		require.NoError(t, loadUser(ctx, db, i))
+	}
+	_, err := db.ExecContext(ctx, "SELECT 1")
+	require.NoError(t, err)
+
+	require.Len(t, reported, 1, "patterns are reported once")
+	p := reported[0]
+	assert.Equal(t, "select name from users where id = ?", p.Query)
+	assert.Equal(t, 3, p.Count)
+	require.Len(t, p.CallSites, 1)
+	assert.True(t, strings.HasPrefix(filepath.Base(p.CallSites[0]), "nplusone_test.go:"), p.CallSites[0])
+
+	patterns := Patterns(ctx)
+	require.Len(t, patterns, 1)
+	assert.Equal(t, 5, patterns[0].Count)
+
+	// new scopes start from scratch
+	ctx = NewScope(context.Background())
+	require.NoError(t, loadUser(ctx, db, 1))
+	assert.Empty(t, Patterns(ctx))
+	assert.Len(t, reported, 1)
+}
+
+func TestStrict(t *testing.T) {
+	hook := New(WithThreshold(2), WithStrict())
+	db := openDB(t, hook)
+
+	ctx := NewScope(context.Background())
+	require.NoError(t, loadUser(ctx, db, 1))
+	err := loadUser(ctx, db, 2)
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, ErrNPlusOne))
+
+	var nErr *Error
+	require.True(t, errors.As(err, &nErr))
+	assert.Equal(t, 2, nErr.Pattern.Count)
+	assert.Equal(t, "nplusone: N+1 query: run 2 times in scope: select name from users where id = ?", err.Error())
+}
+
+func TestSkipPackages(t *testing.T) {
+	hook := New(WithSkipPackages("github.com/qustavo/sqlhooks/v2/hooks/nplusone.loadUser"))
+	db := openDB(t, hook)
+
+	ctx := NewScope(context.Background())
+	require.NoError(t, loadUser(ctx, db, 1))
+	require.NoError(t, loadUser(ctx, db, 2))
+
+	patterns := Patterns(ctx)
+	require.Len(t, patterns, 1)
+	require.Len(t, patterns[0].CallSites, 2, "callers of loadUser are reported")
+}
+
+func TestSkippedQueries(t *testing.T) {
+	hook := New(WithThreshold(3), WithStrict())
+	db := sqltest.OpenDriver(t, &skipdriver.Driver{}, hook)
+
+	ctx := NewScope(context.Background())
+	for i := 0; i < 2; i++ {
+		_, err := db.ExecContext(ctx, "UPDATE users SET name = ? WHERE id = ?", "gus", i)
+		require.NoError(t, err)
+	}
+
+	patterns := Patterns(ctx)
+	require.Len(t, patterns, 1)
+	assert.Equal(t, 2, patterns[0].Count)
+}
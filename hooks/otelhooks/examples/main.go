@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/hooks/otelhooks"
+	"go.opentelemetry.io/otel"
+)
+
+func main() {
+	hooks := otelhooks.New(otel.GetTracerProvider(), otelhooks.WithDBSystem("sqlite"))
+	sql.Register("sqlite3otel", sqlhooks.Wrap(&sqlite3.SQLiteDriver{}, hooks))
+	db, err := sql.Open("sqlite3otel", ":memory:")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, span := otel.Tracer("example").Start(context.Background(), "sql")
+	defer span.End()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE users(ID int, name text)"); err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (id, name) VALUES(?, ?)`, 1, "gus"); err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := db.QueryContext(ctx, `SELECT id, name FROM users`); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,171 @@
+package otelhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/querylabel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// Attribute keys set on measurements besides the semantic conventions ones.
+const (
+	// QueryNameKey holds the name given to a query, see WithQueryName.
+	QueryNameKey = attribute.Key("db.query.name")
+	// ErrorTypeKey holds the class of the errors counted, see Metrics.
+	ErrorTypeKey = attribute.Key("error.type")
+	// InstanceKey holds the name given to sqlhooks.WithInstance.
+	InstanceKey = attribute.Key("db.instance")
+)
+
+// OtherQueries is the query name measurements are recorded with once the
+// limit set with WithMaxQueryNames is reached, and the operation of unknown
+// statements.
+const OtherQueries = querylabel.Other
+
+// WithQueryName sets the function naming queries for the db.query.name
+// attribute of measurements, i.e: QueryNameFromContext. Queries named "" don't
+// have the attribute.
+func WithQueryName(fn func(ctx context.Context, query string) string) Option {
+	return func(c *config) {
+		c.queryName = fn
+	}
+}
+
+// WithMaxQueryNames bounds the number of distinct db.query.name values to n,
+// queries with names seen after the limit is reached are recorded as
+// OtherQueries. It defaults to 100, 0 means no limit.
+func WithMaxQueryNames(n int) Option {
+	return func(c *config) {
+		c.maxQueryNames = n
+	}
+}
+
+// ContextWithQueryName returns a copy of ctx naming the queries run with it.
+// The name is also seen by promhooks.QueryNameFromContext.
+func ContextWithQueryName(ctx context.Context, name string) context.Context {
+	return querylabel.ContextWithName(ctx, name)
+}
+
+// QueryNameFromContext returns the name given with ContextWithQueryName, or
+// promhooks.ContextWithQueryName. It's meant to be given to WithQueryName.
+func QueryNameFromContext(ctx context.Context, query string) string {
+	return querylabel.NameFromContext(ctx, query)
+}
+
+// Metrics is a hook recording the db.client.operation.duration histogram, in
+// seconds, the db.client.operation.errors counter by error.type and the
+// db.client.operation.in_flight gauge.
+// Measurements have the db.system, db.name, db.instance and db.operation
+// attributes, as well as the ones set with WithAttributes, the labels given to
+// sqlhooks.WithInstance and, if WithQueryName is used, db.query.name.
+// Operations other than the usual statement keywords, like SELECT or CREATE,
+// are recorded as OtherQueries. Error types are the errclass categories,
+// "canceled", "timeout" or "other".
+type Metrics struct {
+	config
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+	inFlight metric.Int64UpDownCounter
+	names    *querylabel.Names
+}
+
+// NewMetrics returns a Metrics hook creating its instruments with a Meter
+// from provider.
+func NewMetrics(provider metric.MeterProvider, opts ...Option) (*Metrics, error) {
+	m := &Metrics{config: newConfig(append([]Option{WithMaxQueryNames(100)}, opts...))}
+	m.names = querylabel.NewNames(m.maxQueryNames)
+
+	meter := provider.Meter(ScopeName)
+	var err error
+	m.duration, err = meter.Float64Histogram("db.client.operation.duration",
+		metric.WithDescription("Duration of database client operations."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.errors, err = meter.Int64Counter("db.client.operation.errors",
+		metric.WithDescription("Number of failed database client operations."),
+		metric.WithUnit("{operation}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.inFlight, err = meter.Int64UpDownCounter("db.client.operation.in_flight",
+		metric.WithDescription("Number of database client operations in progress."),
+		metric.WithUnit("{operation}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// measurement holds the attributes and start time of a running operation.
+type measurement struct {
+	attrs metric.MeasurementOption
+	start time.Time
+}
+
+type measurementKey struct{}
+
+func (m *Metrics) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	attrs := append([]attribute.KeyValue{m.system}, m.attrs...)
+	attrs = append(attrs, semconv.DBOperation(querylabel.Operation(query)))
+	if m.name != "" {
+		attrs = append(attrs, semconv.DBName(m.name))
+	}
+	if instance, ok := sqlhooks.InstanceFromContext(ctx); ok {
+		attrs = append(attrs, InstanceKey.String(instance.Name))
+		for k, v := range instance.Labels {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+	}
+	if m.queryName != nil {
+		if name := m.queryName(ctx, query); name != "" {
+			attrs = append(attrs, QueryNameKey.String(m.names.Limit(name)))
+		}
+	}
+
+	meas := &measurement{
+		attrs: metric.WithAttributeSet(attribute.NewSet(attrs...)),
+		start: time.Now(),
+	}
+	m.inFlight.Add(ctx, 1, meas.attrs)
+	return context.WithValue(ctx, measurementKey{}, meas), nil
+}
+
+func (m *Metrics) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	m.done(ctx)
+	return ctx, nil
+}
+
+func (m *Metrics) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	if meas := m.done(ctx); meas != nil {
+		m.errors.Add(ctx, 1, meas.attrs, metric.WithAttributes(ErrorTypeKey.String(querylabel.ErrorClass(err))))
+	}
+	return err
+}
+
+// OnSkip removes a statement the driver skipped from
+// db.client.operation.in_flight, without recording a duration for it.
+func (m *Metrics) OnSkip(ctx context.Context, query string, args ...interface{}) {
+	if meas, ok := ctx.Value(measurementKey{}).(*measurement); ok {
+		m.inFlight.Add(ctx, -1, meas.attrs)
+	}
+}
+
+func (m *Metrics) done(ctx context.Context) *measurement {
+	meas, ok := ctx.Value(measurementKey{}).(*measurement)
+	if !ok {
+		return nil
+	}
+	m.inFlight.Add(ctx, -1, meas.attrs)
+	m.duration.Record(ctx, time.Since(meas.start).Seconds(), meas.attrs)
+	return meas
+}
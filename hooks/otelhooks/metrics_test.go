@@ -0,0 +1,97 @@
+package otelhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		assert.Equal(t, ScopeName, sm.Scope.Name)
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func set(attrs ...attribute.KeyValue) attribute.Set {
+	return attribute.NewSet(attrs...)
+}
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	hook, err := NewMetrics(provider, WithDBSystem("sqlite"), WithQueryName(QueryNameFromContext))
+	require.NoError(t, err)
+	db := sqltest.Open(t, hook, sqlhooks.WithInstance("primary", nil))
+
+	ctx := ContextWithQueryName(context.Background(), "create_users")
+	_, err = db.ExecContext(ctx, "CREATE TABLE users(id int primary key)")
+	require.NoError(t, err)
+	_, err = db.Exec("VACUUM")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = db.Exec("INSERT INTO users(id) VALUES(1)")
+	}
+	require.Error(t, err)
+
+	metrics := collect(t, reader)
+
+	duration := metrics["db.client.operation.duration"].(metricdata.Histogram[float64])
+	counts := make(map[attribute.Set]uint64)
+	for _, dp := range duration.DataPoints {
+		counts[dp.Attributes] = dp.Count
+	}
+	createAttrs := set(
+		attribute.String("db.system", "sqlite"),
+		attribute.String("db.instance", "primary"),
+		attribute.String("db.operation", "CREATE"),
+		attribute.String("db.query.name", "create_users"),
+	)
+	insertAttrs := set(
+		attribute.String("db.system", "sqlite"),
+		attribute.String("db.instance", "primary"),
+		attribute.String("db.operation", "INSERT"),
+	)
+	otherAttrs := set(
+		attribute.String("db.system", "sqlite"),
+		attribute.String("db.instance", "primary"),
+		attribute.String("db.operation", OtherQueries),
+	)
+	assert.Equal(t, map[attribute.Set]uint64{createAttrs: 1, otherAttrs: 1, insertAttrs: 2}, counts)
+
+	errs := metrics["db.client.operation.errors"].(metricdata.Sum[int64])
+	require.Len(t, errs.DataPoints, 1)
+	assert.Equal(t, int64(1), errs.DataPoints[0].Value)
+	assert.Equal(t, set(append(insertAttrs.ToSlice(), attribute.String("error.type", "unique_violation"))...),
+		errs.DataPoints[0].Attributes)
+
+	inFlight := metrics["db.client.operation.in_flight"].(metricdata.Sum[int64])
+	assert.False(t, inFlight.IsMonotonic)
+	for _, dp := range inFlight.DataPoints {
+		assert.Equal(t, int64(0), dp.Value, dp.Attributes)
+	}
+}
+
+func TestMetricsMaxQueryNames(t *testing.T) {
+	hook, err := NewMetrics(sdkmetric.NewMeterProvider(), WithMaxQueryNames(2))
+	require.NoError(t, err)
+
+	assert.Equal(t, "a", hook.names.Limit("a"))
+	assert.Equal(t, "b", hook.names.Limit("b"))
+	assert.Equal(t, OtherQueries, hook.names.Limit("c"))
+	assert.Equal(t, "a", hook.names.Limit("a"))
+}
@@ -0,0 +1,162 @@
+// Package otelhooks traces queries with OpenTelemetry, following the
+// database client semantic conventions.
+package otelhooks
+
+import (
+	"context"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/sqlscan"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the Tracer.
+const ScopeName = "github.com/qustavo/sqlhooks/v2/hooks/otelhooks"
+
+// Option configures a Hook or Metrics.
+type Option func(*config)
+
+// config holds the settings shared by Hook and Metrics.
+type config struct {
+	system      attribute.KeyValue
+	name        string
+	attrs       []attribute.KeyValue
+	noStatement bool
+
+	queryName     func(ctx context.Context, query string) string
+	maxQueryNames int
+}
+
+func newConfig(opts []Option) config {
+	c := config{system: semconv.DBSystemOtherSQL}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithDBSystem sets the db.system attribute, i.e: "postgresql" or "sqlite".
+// It defaults to "other_sql".
+func WithDBSystem(system string) Option {
+	return func(c *config) {
+		c.system = semconv.DBSystemKey.String(system)
+	}
+}
+
+// WithDBName sets the db.name attribute, the name of the database being
+// accessed.
+func WithDBName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithAttributes adds attrs to every span and measurement.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(c *config) {
+		c.attrs = append(c.attrs, attrs...)
+	}
+}
+
+// WithoutStatement omits the db.statement span attribute, for queries that
+// may embed sensitive values.
+func WithoutStatement() Option {
+	return func(c *config) {
+		c.noStatement = true
+	}
+}
+
+type Hook struct {
+	config
+	tracer trace.Tracer
+}
+
+// New returns a Hook creating spans with a Tracer from provider.
+func New(provider trace.TracerProvider, opts ...Option) *Hook {
+	return &Hook{
+		config: newConfig(opts),
+		tracer: provider.Tracer(ScopeName),
+	}
+}
+
+// SkippedKey is set on the spans of the statements the driver skipped, see
+// OnSkip.
+const SkippedKey = attribute.Key("db.skipped")
+
+// spanKey holds the span started by the Hook, so that only those are ended.
+type spanKey struct{}
+
+func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	op := Operation(query)
+	attrs := append([]attribute.KeyValue{h.system}, h.attrs...)
+	if op != "" {
+		attrs = append(attrs, semconv.DBOperation(op))
+	}
+	if h.name != "" {
+		attrs = append(attrs, semconv.DBName(h.name))
+	}
+	if !h.noStatement {
+		attrs = append(attrs, semconv.DBStatement(query))
+	}
+	if instance, ok := sqlhooks.InstanceFromContext(ctx); ok {
+		for k, v := range instance.Labels {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+	}
+
+	ctx, span := h.tracer.Start(ctx, h.spanName(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return context.WithValue(ctx, spanKey{}, span), nil
+}
+
+func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	if span, ok := ctx.Value(spanKey{}).(trace.Span); ok {
+		span.End()
+	}
+
+	return ctx, nil
+}
+
+func (h *Hook) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	if span, ok := ctx.Value(spanKey{}).(trace.Span); ok {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+	}
+
+	return err
+}
+
+// OnSkip ends the span of a statement the driver skipped with SkippedKey set,
+// the prepared statement database/sql falls back to is traced separately.
+func (h *Hook) OnSkip(ctx context.Context, query string, args ...interface{}) {
+	if span, ok := ctx.Value(spanKey{}).(trace.Span); ok {
+		span.SetAttributes(SkippedKey.Bool(true))
+		span.End()
+	}
+}
+
+// spanName follows the conventions, naming spans after the operation and the
+// database, i.e: "SELECT shop". It's "DB" when neither is known.
+func (h *Hook) spanName(op string) string {
+	switch {
+	case op != "" && h.name != "":
+		return op + " " + h.name
+	case op != "":
+		return op
+	case h.name != "":
+		return h.name
+	}
+	return "DB"
+}
+
+// Operation returns the uppercased keyword query starts with, i.e: "SELECT"
+// or "INSERT", skipping leading comments. It's empty if there's none.
+func Operation(query string) string {
+	return sqlscan.Keyword(sqlscan.Any, query)
+}
@@ -0,0 +1,115 @@
+package otelhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/skipdriver"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), recorder
+}
+
+func attrs(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	m := make(map[attribute.Key]string)
+	for _, kv := range span.Attributes() {
+		m[kv.Key] = kv.Value.Emit()
+	}
+	return m
+}
+
+func TestSpans(t *testing.T) {
+	provider, recorder := newProvider()
+	db := sqltest.Open(t, New(provider, WithDBSystem("sqlite"), WithDBName("shop")))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	_, err := db.ExecContext(ctx, "CREATE TABLE users(id int)")
+	require.NoError(t, err)
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users WHERE id = ?", 1)
+	require.NoError(t, err)
+	rows.Close()
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	span := spans[1]
+	assert.Equal(t, "SELECT shop", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+	assert.Equal(t, map[attribute.Key]string{
+		"db.system":    "sqlite",
+		"db.name":      "shop",
+		"db.operation": "SELECT",
+		"db.statement": "SELECT id FROM users WHERE id = ?",
+	}, attrs(span))
+	assert.Equal(t, ScopeName, span.InstrumentationScope().Name)
+	assert.Equal(t, "CREATE shop", spans[0].Name())
+}
+
+func TestSpansErrors(t *testing.T) {
+	provider, recorder := newProvider()
+	db := sqltest.Open(t, New(provider, WithoutStatement(), WithAttributes(attribute.String("team", "payments"))),
+		sqlhooks.WithInstance("primary", map[string]string{"db.instance": "primary"}))
+
+	_, err := db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "SELECT", span.Name())
+	assert.False(t, span.Parent().IsValid())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Equal(t, err.Error(), span.Status().Description)
+	require.Len(t, span.Events(), 1)
+	assert.Equal(t, "exception", span.Events()[0].Name)
+	assert.Equal(t, map[attribute.Key]string{
+		"db.system":    "other_sql",
+		"db.operation": "SELECT",
+		"db.instance":  "primary",
+		"team":         "payments",
+	}, attrs(span))
+}
+
+func TestSkippedSpans(t *testing.T) {
+	provider, recorder := newProvider()
+	db := sqltest.OpenDriver(t, &skipdriver.Driver{}, New(provider))
+
+	_, err := db.Exec("INSERT INTO users VALUES(?)", 1)
+	require.NoError(t, err)
+
+	// the skipped attempt and the prepared statement
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "true", attrs(spans[0])[SkippedKey])
+	assert.NotContains(t, attrs(spans[1]), SkippedKey)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+}
+
+func TestOperation(t *testing.T) {
+	for query, want := range map[string]string{
+		"SELECT 1":                           "SELECT",
+		"  insert into users values(1)":      "INSERT",
+		"-- list users\nselect * from users": "SELECT",
+		"/* app=api */ UPDATE users SET x=1": "UPDATE",
+		"(SELECT 1) UNION (SELECT 2)":        "SELECT",
+		"":                                   "",
+		"-- comment":                         "",
+	} {
+		assert.Equal(t, want, Operation(query), query)
+	}
+}
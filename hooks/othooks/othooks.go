@@ -2,46 +2,237 @@ package othooks
 
 import (
 	"context"
+	"database/sql/driver"
+	"strings"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
+	"github.com/qustavo/sqlhooks/v2"
 )
 
+// StandardTags are the values of the standard OpenTracing database tags,
+// see WithStandardTags.
+type StandardTags struct {
+	// DBType is the db.type tag, it defaults to "sql".
+	DBType string
+	// DBInstance is the db.instance tag, it defaults to the name given to
+	// sqlhooks.WithInstance.
+	DBInstance string
+	// PeerService is the peer.service tag, it's omitted if empty.
+	PeerService string
+}
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithStandardTags adds the db.type, db.instance, db.statement, span.kind
+// and peer.service tags to the spans. The statement is also logged as a tag,
+// so the query log field is omitted.
+func WithStandardTags(tags StandardTags) Option {
+	if tags.DBType == "" {
+		tags.DBType = "sql"
+	}
+
+	return func(h *Hook) {
+		h.tags = &tags
+	}
+}
+
+// WithSpanName sets the function used to name spans, they're named "sql" by
+// default. See OperationName.
+func WithSpanName(fn func(ctx context.Context, query string) string) Option {
+	return func(h *Hook) {
+		h.spanName = fn
+	}
+}
+
+// WithRootSpans makes the Hook create spans for queries without a parent
+// span in their context.
+func WithRootSpans() Option {
+	return func(h *Hook) {
+		h.rootSpans = true
+	}
+}
+
+// WithoutArgs disables logging the arguments of queries.
+func WithoutArgs() Option {
+	return func(h *Hook) {
+		h.noArgs = true
+	}
+}
+
+// WithRowsSpans makes query spans last until their rows are closed, so they
+// include the time spent reading them. The number of rows read is set as the
+// db.rows tag.
+func WithRowsSpans() Option {
+	return func(h *Hook) {
+		h.rowsSpans = true
+	}
+}
+
 type Hook struct {
-	tracer opentracing.Tracer
+	tracer    opentracing.Tracer
+	tags      *StandardTags
+	spanName  func(ctx context.Context, query string) string
+	rootSpans bool
+	noArgs    bool
+	rowsSpans bool
+}
+
+func New(tracer opentracing.Tracer, opts ...Option) *Hook {
+	h := &Hook{tracer: tracer}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-func New(tracer opentracing.Tracer) *Hook {
-	return &Hook{tracer: tracer}
+// spanKey holds the span started by the Hook, so that only those are
+// finished.
+type spanKey struct{}
+
+// txSpanKey holds the span started by the Hook for a transaction.
+type txSpanKey struct{}
+
+// BeginTx starts a "sql.tx" span, which is the parent of the spans of the
+// statements run within the transaction.
+func (h *Hook) BeginTx(ctx context.Context, opts driver.TxOptions) (context.Context, error) {
+	var spanOpts []opentracing.StartSpanOption
+	if parent := opentracing.SpanFromContext(ctx); parent != nil {
+		spanOpts = append(spanOpts, opentracing.ChildOf(parent.Context()))
+	} else if !h.rootSpans {
+		return ctx, nil
+	}
+
+	span := h.tracer.StartSpan("sql.tx", spanOpts...)
+	if instance, ok := sqlhooks.InstanceFromContext(ctx); ok {
+		span.SetTag("db.instance", instance.Name)
+		for k, v := range instance.Labels {
+			span.SetTag(k, v)
+		}
+	}
+	if h.tags != nil {
+		ext.SpanKindRPCClient.Set(span)
+		ext.DBType.Set(span, h.tags.DBType)
+		if h.tags.DBInstance != "" {
+			ext.DBInstance.Set(span, h.tags.DBInstance)
+		}
+		if h.tags.PeerService != "" {
+			ext.PeerService.Set(span, h.tags.PeerService)
+		}
+	}
+	if opts.ReadOnly {
+		span.SetTag("db.tx.read_only", true)
+	}
+
+	ctx = opentracing.ContextWithSpan(ctx, span)
+	return context.WithValue(ctx, txSpanKey{}, span), nil
+}
+
+// EndTx finishes the transaction span, setting how it ended as the db.tx.end
+// tag.
+func (h *Hook) EndTx(ctx context.Context, end sqlhooks.TxEnd, err error) error {
+	if span, ok := ctx.Value(txSpanKey{}).(opentracing.Span); ok {
+		defer span.Finish()
+		span.SetTag("db.tx.end", end.String())
+		if err != nil {
+			span.SetTag("error", true)
+			span.LogFields(log.Error(err))
+		}
+	}
+
+	return err
 }
 
 func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
 	parent := opentracing.SpanFromContext(ctx)
-	if parent == nil {
+	if tx, ok := sqlhooks.TxFromContext(ctx); ok {
+		if span, ok := tx.Value(txSpanKey{}).(opentracing.Span); ok {
+			parent = span
+		}
+	}
+
+	var opts []opentracing.StartSpanOption
+	if parent != nil {
+		opts = append(opts, opentracing.ChildOf(parent.Context()))
+	} else if !h.rootSpans {
 		return ctx, nil
 	}
 
-	span := h.tracer.StartSpan("sql", opentracing.ChildOf(parent.Context()))
-	span.LogFields(
-		log.String("query", query),
-		log.Object("args", args),
-	)
+	name := "sql"
+	if h.spanName != nil {
+		name = h.spanName(ctx, query)
+	}
+
+	span := h.tracer.StartSpan(name, opts...)
+	instance, hasInstance := sqlhooks.InstanceFromContext(ctx)
+	if hasInstance {
+		span.SetTag("db.instance", instance.Name)
+		for k, v := range instance.Labels {
+			span.SetTag(k, v)
+		}
+	}
+
+	var fields []log.Field
+	if h.tags != nil {
+		ext.SpanKindRPCClient.Set(span)
+		ext.DBType.Set(span, h.tags.DBType)
+		ext.DBStatement.Set(span, query)
+		if h.tags.DBInstance != "" {
+			ext.DBInstance.Set(span, h.tags.DBInstance)
+		}
+		if h.tags.PeerService != "" {
+			ext.PeerService.Set(span, h.tags.PeerService)
+		}
+	} else {
+		fields = append(fields, log.String("query", query))
+	}
+	if !h.noArgs {
+		fields = append(fields, log.Object("args", args))
+	}
+	if len(fields) > 0 {
+		span.LogFields(fields...)
+	}
 
-	return opentracing.ContextWithSpan(ctx, span), nil
+	ctx = opentracing.ContextWithSpan(ctx, span)
+	return context.WithValue(ctx, spanKey{}, span), nil
 }
 
 func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
-	span := opentracing.SpanFromContext(ctx)
-	if span != nil {
+	if h.rowsSpans {
+		switch sqlhooks.OpFromContext(ctx) {
+		case sqlhooks.OpQuery, sqlhooks.OpStmtQuery:
+			// finished by OnRowsClose
+			return ctx, nil
+		}
+	}
+
+	if span, ok := ctx.Value(spanKey{}).(opentracing.Span); ok {
 		defer span.Finish()
 	}
 
 	return ctx, nil
 }
 
+// OnRowsClose finishes query spans when WithRowsSpans is used.
+func (h *Hook) OnRowsClose(ctx context.Context, query string, rows int, err error) {
+	if !h.rowsSpans {
+		return
+	}
+
+	if span, ok := ctx.Value(spanKey{}).(opentracing.Span); ok {
+		defer span.Finish()
+		span.SetTag("db.rows", rows)
+		if err != nil {
+			span.SetTag("error", true)
+			span.LogFields(log.Error(err))
+		}
+	}
+}
+
 func (h *Hook) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
-	span := opentracing.SpanFromContext(ctx)
-	if span != nil {
+	if span, ok := ctx.Value(spanKey{}).(opentracing.Span); ok {
 		defer span.Finish()
 		span.SetTag("error", true)
 		span.LogFields(
@@ -51,3 +242,46 @@ func (h *Hook) OnError(ctx context.Context, err error, query string, args ...int
 
 	return err
 }
+
+// OnSkip finishes the span of statements the driver skipped, tagged with
+// db.skipped. database/sql runs them again as prepared statements, which get a
+// span of their own.
+func (h *Hook) OnSkip(ctx context.Context, query string, args ...interface{}) {
+	if span, ok := ctx.Value(spanKey{}).(opentracing.Span); ok {
+		span.SetTag("db.skipped", true)
+		span.Finish()
+	}
+}
+
+// OperationName names spans after the statement keyword and the table it
+// operates on, i.e: "SELECT users" or "INSERT orders". It's meant to be
+// given to WithSpanName.
+func OperationName(ctx context.Context, query string) string {
+	fields := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ", ",", " , ", ";", " ; ").Replace(query))
+	if len(fields) == 0 {
+		return "sql"
+	}
+
+	op := strings.ToUpper(fields[0])
+	var after string
+	switch op {
+	case "SELECT", "DELETE":
+		after = "FROM"
+	case "INSERT", "REPLACE":
+		after = "INTO"
+	case "UPDATE":
+		if len(fields) > 1 {
+			return op + " " + fields[1]
+		}
+		return op
+	default:
+		return op
+	}
+
+	for i, f := range fields[:len(fields)-1] {
+		if strings.EqualFold(f, after) && fields[i+1] != "(" {
+			return op + " " + fields[i+1]
+		}
+	}
+	return op
+}
@@ -5,10 +5,13 @@ import (
 	"database/sql"
 	"testing"
 
-	"github.com/qustavo/sqlhooks/v2"
 	sqlite3 "github.com/mattn/go-sqlite3"
 	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/skipdriver"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -72,3 +75,214 @@ func TestNoSpansAreRecorded(t *testing.T) {
 
 	assert.Empty(t, tracer.FinishedSpans())
 }
+
+func TestSkippedSpansAreFinished(t *testing.T) {
+	tracer := mocktracer.New()
+	db := sqltest.OpenDriver(t, &skipdriver.Driver{}, New(tracer))
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+	_, err := db.ExecContext(ctx, "INSERT INTO users VALUES(?)", 1)
+	require.NoError(t, err)
+	parent.Finish()
+
+	// the skipped attempt and the prepared statement
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 3)
+	for _, span := range spans[:2] {
+		assert.Equal(t, "sql", span.OperationName)
+		assert.Nil(t, span.Tag("error"))
+	}
+	assert.Equal(t, true, spans[0].Tag("db.skipped"))
+	assert.Nil(t, spans[1].Tag("db.skipped"))
+}
+
+// openInstanceDB opens a database of the "analytics" instance traced by tracer.
+func openInstanceDB(t *testing.T, tracer opentracing.Tracer) *sql.DB {
+	return sqltest.Open(t, New(tracer),
+		sqlhooks.WithInstance("analytics", map[string]string{"db.system": "sqlite"}),
+	)
+}
+
+func TestSpansHaveInstanceTags(t *testing.T) {
+	tracer := mocktracer.New()
+	db := openInstanceDB(t, tracer)
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+	rows, err := db.QueryContext(ctx, "SELECT 1")
+	require.NoError(t, err)
+	rows.Close()
+	parent.Finish()
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 2)
+
+	tags := spans[0].Tags()
+	assert.Equal(t, "analytics", tags["db.instance"])
+	assert.Equal(t, "sqlite", tags["db.system"])
+}
+
+func TestStandardTags(t *testing.T) {
+	tracer := mocktracer.New()
+	db := sqltest.Open(t, New(tracer,
+		WithStandardTags(StandardTags{PeerService: "users-db"}),
+		WithSpanName(OperationName),
+		WithoutArgs(),
+	), sqlhooks.WithInstance("primary", nil))
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+	_, err := db.ExecContext(ctx, "CREATE TABLE users(id int)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "SELECT id FROM users WHERE id = ?", 1)
+	require.NoError(t, err)
+	parent.Finish()
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "CREATE", spans[0].OperationName)
+
+	span := spans[1]
+	assert.Equal(t, "SELECT users", span.OperationName)
+	assert.Equal(t, map[string]interface{}{
+		"db.type":      "sql",
+		"db.instance":  "primary",
+		"db.statement": "SELECT id FROM users WHERE id = ?",
+		"span.kind":    ext.SpanKindRPCClientEnum,
+		"peer.service": "users-db",
+	}, span.Tags())
+	assert.Empty(t, span.Logs(), "args must not be logged")
+}
+
+func TestRootSpans(t *testing.T) {
+	tracer := mocktracer.New()
+	db := sqltest.Open(t, New(tracer, WithRootSpans()))
+
+	_, err := db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "sql", spans[0].OperationName)
+	assert.Equal(t, 0, spans[0].ParentID)
+	assert.Equal(t, true, spans[0].Tag("error"))
+}
+
+func TestOperationName(t *testing.T) {
+	for query, want := range map[string]string{
+		"SELECT * FROM users WHERE id = 1":   "SELECT users",
+		"select count(*) from orders":        "SELECT orders",
+		"SELECT * FROM (SELECT 1) AS t":      "SELECT",
+		"INSERT INTO users(id) VALUES(1)":    "INSERT users",
+		"UPDATE users SET name = 'gus'":      "UPDATE users",
+		"DELETE FROM sessions WHERE expired": "DELETE sessions",
+		"BEGIN":                              "BEGIN",
+		"":                                   "sql",
+	} {
+		assert.Equal(t, want, OperationName(context.Background(), query), query)
+	}
+}
+
+func TestTxSpans(t *testing.T) {
+	tracer := mocktracer.New()
+	db := sqltest.Open(t, New(tracer, WithSpanName(OperationName)))
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+	_, err := db.ExecContext(ctx, "CREATE TABLE users(id int)")
+	require.NoError(t, err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "INSERT INTO users(id) VALUES(1)")
+	require.NoError(t, err)
+	_, err = tx.ExecContext(context.Background(), "INSERT INTO users(id) VALUES(2)")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	_, err = db.ExecContext(ctx, "DELETE FROM users")
+	require.NoError(t, err)
+	parent.Finish()
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 6)
+	create, insert1, insert2, txSpan, del := spans[0], spans[1], spans[2], spans[3], spans[4]
+	parentID := spans[5].SpanContext.SpanID
+
+	assert.Equal(t, "sql.tx", txSpan.OperationName)
+	assert.Equal(t, "commit", txSpan.Tag("db.tx.end"))
+	assert.Equal(t, parentID, txSpan.ParentID)
+	assert.Equal(t, txSpan.SpanContext.SpanID, insert1.ParentID)
+	assert.Equal(t, txSpan.SpanContext.SpanID, insert2.ParentID, "statements in a tx are traced without a span in their context")
+	assert.Equal(t, parentID, create.ParentID)
+	assert.Equal(t, parentID, del.ParentID)
+}
+
+func TestTxSpansHaveInstanceTags(t *testing.T) {
+	tracer := mocktracer.New()
+	db := openInstanceDB(t, tracer)
+
+	parent := tracer.StartSpan("parent")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "sql.tx", spans[0].OperationName)
+	assert.Equal(t, "analytics", spans[0].Tag("db.instance"))
+	assert.Equal(t, "sqlite", spans[0].Tag("db.system"))
+}
+
+func TestTxSpansRollback(t *testing.T) {
+	tracer := mocktracer.New()
+	db := sqltest.Open(t, New(tracer, WithRootSpans()))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "sql.tx", spans[0].OperationName)
+	assert.Equal(t, "rollback", spans[0].Tag("db.tx.end"))
+	assert.Nil(t, spans[0].Tag("error"))
+}
+
+func TestRowsSpans(t *testing.T) {
+	tracer := mocktracer.New()
+	db := sqltest.Open(t, New(tracer, WithRowsSpans(), WithRootSpans()))
+
+	_, err := db.Exec("CREATE TABLE users(id int)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users(id) VALUES(1), (2), (3)")
+	require.NoError(t, err)
+	require.Len(t, tracer.FinishedSpans(), 2)
+
+	rows, err := db.Query("SELECT id FROM users")
+	require.NoError(t, err)
+	require.Len(t, tracer.FinishedSpans(), 2, "span must be open while reading rows")
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Close())
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, 3, spans[2].Tag("db.rows"))
+	assert.Nil(t, spans[0].Tag("db.rows"), "exec spans don't have rows")
+
+	stmt, err := db.Prepare("SELECT id FROM users WHERE id > ?")
+	require.NoError(t, err)
+	defer stmt.Close()
+	var id int
+	require.NoError(t, stmt.QueryRow(2).Scan(&id))
+
+	spans = tracer.FinishedSpans()
+	require.Len(t, spans, 4)
+	assert.Equal(t, 1, spans[3].Tag("db.rows"))
+}
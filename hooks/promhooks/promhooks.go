@@ -0,0 +1,217 @@
+// Package promhooks exposes Prometheus metrics about the queries run.
+package promhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/querylabel"
+)
+
+// OtherQueries is the query label of queries whose name exceeds the limit set
+// with WithMaxQueryNames, and the operation label of unknown statements.
+const OtherQueries = querylabel.Other
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithNamespace sets the namespace metrics are prefixed with, it defaults
+// to "sql".
+func WithNamespace(namespace string) Option {
+	return func(h *Hook) {
+		h.namespace = namespace
+	}
+}
+
+// WithBuckets sets the buckets of the query duration histogram, in seconds.
+// It defaults to prometheus.DefBuckets.
+func WithBuckets(buckets ...float64) Option {
+	return func(h *Hook) {
+		h.buckets = buckets
+	}
+}
+
+// WithConstLabels adds labels with fixed values to every metric.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(h *Hook) {
+		h.constLabels = labels
+	}
+}
+
+// WithQueryName sets the function naming queries for the query label, i.e:
+// QueryNameFromContext. Queries named "" have an empty label.
+func WithQueryName(fn func(ctx context.Context, query string) string) Option {
+	return func(h *Hook) {
+		h.queryName = fn
+	}
+}
+
+// WithMaxQueryNames bounds the number of distinct query label values to n,
+// names seen after the limit is reached are replaced with OtherQueries. It
+// defaults to 100, 0 means no limit.
+func WithMaxQueryNames(n int) Option {
+	return func(h *Hook) {
+		h.maxQueryNames = n
+	}
+}
+
+// ContextWithQueryName returns a copy of ctx naming the queries run with it.
+// The name is also seen by otelhooks.QueryNameFromContext.
+func ContextWithQueryName(ctx context.Context, name string) context.Context {
+	return querylabel.ContextWithName(ctx, name)
+}
+
+// QueryNameFromContext returns the name given with ContextWithQueryName, or
+// otelhooks.ContextWithQueryName. It's meant to be given to WithQueryName.
+func QueryNameFromContext(ctx context.Context, query string) string {
+	return querylabel.NameFromContext(ctx, query)
+}
+
+// Hook records the following metrics, prefixed with the namespace:
+//
+//   - query_duration_seconds, a histogram of query latencies.
+//   - queries_total, a counter of queries run.
+//   - query_errors_total, a counter of failed queries, by error class.
+//   - queries_in_flight, a gauge of queries in progress.
+//
+// They're labeled by operation (the statement keyword), instance (the name
+// given to sqlhooks.WithInstance) and query (see WithQueryName), except for
+// the gauge, which has no query label. Error classes are the errclass
+// categories, "canceled", "timeout" or "other".
+//
+// Hook is a prometheus.Collector, see Register.
+type Hook struct {
+	namespace     string
+	buckets       []float64
+	constLabels   prometheus.Labels
+	queryName     func(ctx context.Context, query string) string
+	maxQueryNames int
+
+	duration *prometheus.HistogramVec
+	queries  *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+	names    *querylabel.Names
+}
+
+func New(opts ...Option) *Hook {
+	h := &Hook{
+		namespace:     "sql",
+		buckets:       prometheus.DefBuckets,
+		maxQueryNames: 100,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.names = querylabel.NewNames(h.maxQueryNames)
+
+	labels := []string{"operation", "instance", "query"}
+	h.duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   h.namespace,
+		Name:        "query_duration_seconds",
+		Help:        "Duration of queries in seconds.",
+		Buckets:     h.buckets,
+		ConstLabels: h.constLabels,
+	}, labels)
+	h.queries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   h.namespace,
+		Name:        "queries_total",
+		Help:        "Number of queries run.",
+		ConstLabels: h.constLabels,
+	}, labels)
+	h.errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   h.namespace,
+		Name:        "query_errors_total",
+		Help:        "Number of failed queries by error class.",
+		ConstLabels: h.constLabels,
+	}, append(labels, "class"))
+	h.inFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   h.namespace,
+		Name:        "queries_in_flight",
+		Help:        "Number of queries in progress.",
+		ConstLabels: h.constLabels,
+	}, labels[:2])
+	return h
+}
+
+// Register registers the metrics of h with r, i.e: prometheus.DefaultRegisterer.
+func (h *Hook) Register(r prometheus.Registerer) error {
+	return r.Register(h)
+}
+
+// MustRegister is like Register but panics if the metrics can't be
+// registered.
+func (h *Hook) MustRegister(r prometheus.Registerer) {
+	r.MustRegister(h)
+}
+
+func (h *Hook) Describe(ch chan<- *prometheus.Desc) {
+	h.duration.Describe(ch)
+	h.queries.Describe(ch)
+	h.errors.Describe(ch)
+	h.inFlight.Describe(ch)
+}
+
+func (h *Hook) Collect(ch chan<- prometheus.Metric) {
+	h.duration.Collect(ch)
+	h.queries.Collect(ch)
+	h.errors.Collect(ch)
+	h.inFlight.Collect(ch)
+}
+
+// call holds the labels and start time of a running query.
+type call struct {
+	labels []string
+	start  time.Time
+}
+
+type callKey struct{}
+
+func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	var instance, name string
+	if i, ok := sqlhooks.InstanceFromContext(ctx); ok {
+		instance = i.Name
+	}
+	if h.queryName != nil {
+		if name = h.queryName(ctx, query); name != "" {
+			name = h.names.Limit(name)
+		}
+	}
+
+	c := &call{labels: []string{querylabel.Operation(query), instance, name}, start: time.Now()}
+	h.inFlight.WithLabelValues(c.labels[:2]...).Inc()
+	return context.WithValue(ctx, callKey{}, c), nil
+}
+
+func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	h.done(ctx)
+	return ctx, nil
+}
+
+func (h *Hook) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	if c := h.done(ctx); c != nil {
+		h.errors.WithLabelValues(append(c.labels, querylabel.ErrorClass(err))...).Inc()
+	}
+	return err
+}
+
+// OnSkip takes a statement the driver skipped out of the in-flight gauge,
+// its prepared retry is counted as a query of its own.
+func (h *Hook) OnSkip(ctx context.Context, query string, args ...interface{}) {
+	if c, ok := ctx.Value(callKey{}).(*call); ok {
+		h.inFlight.WithLabelValues(c.labels[:2]...).Dec()
+	}
+}
+
+func (h *Hook) done(ctx context.Context) *call {
+	c, ok := ctx.Value(callKey{}).(*call)
+	if !ok {
+		return nil
+	}
+	h.inFlight.WithLabelValues(c.labels[:2]...).Dec()
+	h.queries.WithLabelValues(c.labels...).Inc()
+	h.duration.WithLabelValues(c.labels...).Observe(time.Since(c.start).Seconds())
+	return c
+}
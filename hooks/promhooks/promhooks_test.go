@@ -0,0 +1,72 @@
+package promhooks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	hook := New(WithQueryName(QueryNameFromContext), WithBuckets(0.1, 1))
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, hook.Register(reg))
+	db := sqltest.Open(t, hook, sqlhooks.WithInstance("primary", nil))
+
+	ctx := ContextWithQueryName(context.Background(), "create_users")
+	_, err := db.ExecContext(ctx, "CREATE TABLE users(id int primary key)")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = db.Exec("INSERT INTO users(id) VALUES(1)")
+	}
+	require.Error(t, err)
+	_, err = db.Exec("VACUUM")
+	require.NoError(t, err)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP sql_queries_total Number of queries run.
+# TYPE sql_queries_total counter
+sql_queries_total{instance="primary",operation="CREATE",query="create_users"} 1
+sql_queries_total{instance="primary",operation="INSERT",query=""} 2
+sql_queries_total{instance="primary",operation="other",query=""} 1
+# HELP sql_query_errors_total Number of failed queries by error class.
+# TYPE sql_query_errors_total counter
+sql_query_errors_total{class="unique_violation",instance="primary",operation="INSERT",query=""} 1
+# HELP sql_queries_in_flight Number of queries in progress.
+# TYPE sql_queries_in_flight gauge
+sql_queries_in_flight{instance="primary",operation="CREATE"} 0
+sql_queries_in_flight{instance="primary",operation="INSERT"} 0
+sql_queries_in_flight{instance="primary",operation="other"} 0
+`), "sql_queries_total", "sql_query_errors_total", "sql_queries_in_flight"))
+
+	assert.Equal(t, 3, testutil.CollectAndCount(hook, "sql_query_duration_seconds"))
+}
+
+func TestNamespaceAndConstLabels(t *testing.T) {
+	hook := New(WithNamespace("app"), WithConstLabels(prometheus.Labels{"service": "api"}))
+	db := sqltest.Open(t, hook)
+
+	_, err := db.Exec("SELECT 1")
+	require.NoError(t, err)
+
+	assert.NoError(t, testutil.CollectAndCompare(hook, strings.NewReader(`
+# HELP app_queries_total Number of queries run.
+# TYPE app_queries_total counter
+app_queries_total{instance="",operation="SELECT",query="",service="api"} 1
+`), "app_queries_total"))
+}
+
+func TestMaxQueryNames(t *testing.T) {
+	hook := New(WithMaxQueryNames(2))
+
+	assert.Equal(t, "a", hook.names.Limit("a"))
+	assert.Equal(t, "b", hook.names.Limit("b"))
+	assert.Equal(t, OtherQueries, hook.names.Limit("c"))
+	assert.Equal(t, "a", hook.names.Limit("a"))
+}
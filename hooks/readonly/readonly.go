@@ -0,0 +1,143 @@
+// Package readonly provides a hook rejecting writes, for read replica code
+// paths and maintenance windows.
+//
+// Statements are classified with the classifier package, and only the ones
+// known to be reads are let through: every statement of a query must be a
+// SELECT not writing to a table, like SELECT INTO does, or control the
+// transaction. Any other statement, including the ones the classifier doesn't
+// know, like GRANT or SET, is rejected. Transactions are writing unless
+// they're started with sql.TxOptions.ReadOnly.
+package readonly
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/classifier"
+)
+
+// ErrReadOnly is matched by the errors of the statements and transactions
+// rejected while reads only are allowed.
+var ErrReadOnly = errors.New("write rejected in read-only mode")
+
+// Error is returned by the writes rejected by the Hook.
+type Error struct {
+	// Query is the rejected statement, it's empty for transactions.
+	Query string
+	// Kind is the kind of the first statement of Query that was rejected.
+	Kind classifier.Kind
+}
+
+func (e *Error) Error() string {
+	if e.Query == "" {
+		return fmt.Sprintf("readonly: %s: writing transaction", ErrReadOnly)
+	}
+	return fmt.Sprintf("readonly: %s: %s statement", ErrReadOnly, e.Kind)
+}
+
+// Is matches ErrReadOnly for rejected statements and transactions alike.
+func (e *Error) Is(target error) bool { return target == ErrReadOnly }
+
+type readOnlyKey struct{}
+
+// WithReadOnly returns a copy of ctx in which writes are rejected.
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// IsReadOnly reports whether ctx was marked with WithReadOnly.
+func IsReadOnly(ctx context.Context) bool {
+	ro, _ := ctx.Value(readOnlyKey{}).(bool)
+	return ro
+}
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithClassifier sets the Classifier used to tell writes, it defaults to the
+// one shared by the classifier package.
+func WithClassifier(c *classifier.Classifier) Option {
+	return func(h *Hook) {
+		h.classify = c.Classify
+	}
+}
+
+// Hook rejects writes in read-only contexts, or in every context while the
+// global switch is on, see SetReadOnly.
+type Hook struct {
+	classify func(query string) classifier.Statement
+	global   atomic.Bool
+}
+
+func New(opts ...Option) *Hook {
+	h := &Hook{classify: classifier.Classify}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// SetReadOnly turns the global switch on or off, making every context
+// read-only while it's on. It's safe to call concurrently with queries.
+func (h *Hook) SetReadOnly(on bool) {
+	h.global.Store(on)
+}
+
+// ReadOnly reports whether the global switch is on.
+func (h *Hook) ReadOnly() bool {
+	return h.global.Load()
+}
+
+func (h *Hook) readOnly(ctx context.Context) bool {
+	return h.global.Load() || IsReadOnly(ctx)
+}
+
+func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	if !h.readOnly(ctx) {
+		return ctx, nil
+	}
+
+	if kind, ok := reads(h.classify(query)); !ok {
+		return ctx, &Error{Query: query, Kind: kind}
+	}
+	return ctx, nil
+}
+
+// reads reports whether stmt is known to only read, returning the kind of
+// its first statement that may not otherwise.
+func reads(stmt classifier.Statement) (classifier.Kind, bool) {
+	if len(stmt.Kinds) == 0 {
+		return classifier.Other, false
+	}
+	for _, kind := range stmt.Kinds {
+		switch kind {
+		case classifier.Select, classifier.TCL:
+		default:
+			return kind, false
+		}
+	}
+	if len(stmt.Writes) > 0 {
+		return classifier.Select, false
+	}
+	return classifier.Select, true
+}
+
+func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	return ctx, nil
+}
+
+// BeginTx rejects transactions not started as read-only.
+func (h *Hook) BeginTx(ctx context.Context, opts driver.TxOptions) (context.Context, error) {
+	if h.readOnly(ctx) && !opts.ReadOnly {
+		return ctx, &Error{}
+	}
+	return ctx, nil
+}
+
+func (h *Hook) EndTx(ctx context.Context, end sqlhooks.TxEnd, err error) error {
+	return err
+}
@@ -0,0 +1,100 @@
+package readonly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2/classifier"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openDB(t *testing.T, hook *Hook) *sql.DB {
+	db := sqltest.Open(t, hook)
+	_, err := db.Exec("CREATE TABLE users(id int)")
+	require.NoError(t, err)
+	return db
+}
+
+func TestReadOnlyContext(t *testing.T) {
+	db := openDB(t, New())
+	ctx := WithReadOnly(context.Background())
+
+	_, err := db.ExecContext(ctx, "SELECT * FROM users")
+	require.NoError(t, err)
+
+	for _, it := range []struct {
+		query string
+		kind  classifier.Kind
+	}{
+		{"INSERT INTO users VALUES (1)", classifier.Insert},
+		{"UPDATE users SET id = 2", classifier.Update},
+		{"DELETE FROM users", classifier.Delete},
+		{"DROP TABLE users", classifier.DDL},
+		{"SELECT 1; DELETE FROM users", classifier.Delete},
+		{"SELECT 1; DROP DATABASE prod", classifier.DDL},
+		{"SELECT 1; DROP SCHEMA public CASCADE", classifier.DDL},
+		{"SELECT 1; GRANT ALL ON users TO bob", classifier.Other},
+		{"MERGE INTO users u USING staging s ON u.id = s.id WHEN MATCHED THEN DELETE", classifier.Merge},
+		{"COPY users FROM STDIN", classifier.Insert},
+		{"SELECT * INTO backup FROM users", classifier.Select},
+		{"CALL purge_users()", classifier.Call},
+		{"LOAD DATA INFILE 'users.csv' INTO TABLE users", classifier.Insert},
+		{"PRAGMA writable_schema = ON", classifier.Other},
+		{"WITH x AS (UPDATE users SET id = 1 RETURNING *) SELECT * FROM x", classifier.Select},
+		{"DELETE a FROM a JOIN b ON a.id = b.id", classifier.Delete},
+	} {
+		_, err := db.ExecContext(ctx, it.query)
+		require.Error(t, err, it.query)
+		assert.True(t, errors.Is(err, ErrReadOnly), it.query)
+
+		var roErr *Error
+		require.True(t, errors.As(err, &roErr))
+		assert.Equal(t, it.query, roErr.Query)
+		assert.Equal(t, it.kind, roErr.Kind)
+	}
+
+	_, err = db.Exec("INSERT INTO users VALUES (1)")
+	require.NoError(t, err, "writes are allowed outside read-only contexts")
+	assert.Equal(t, "readonly: write rejected in read-only mode: UPDATE statement",
+		(&Error{Query: "UPDATE users SET id = 1", Kind: classifier.Update}).Error())
+}
+
+func TestReadOnlyTransactions(t *testing.T) {
+	db := openDB(t, New())
+	ctx := WithReadOnly(context.Background())
+
+	_, err := db.BeginTx(ctx, nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrReadOnly))
+	assert.Equal(t, "readonly: write rejected in read-only mode: writing transaction", err.Error())
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	require.NoError(t, err)
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, "SELECT * FROM users")
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "INSERT INTO users VALUES (1)")
+	assert.True(t, errors.Is(err, ErrReadOnly))
+}
+
+func TestGlobalSwitch(t *testing.T) {
+	hook := New()
+	db := openDB(t, hook)
+
+	hook.SetReadOnly(true)
+	assert.True(t, hook.ReadOnly())
+	_, err := db.Exec("INSERT INTO users VALUES (1)")
+	assert.True(t, errors.Is(err, ErrReadOnly))
+	_, err = db.Begin()
+	assert.True(t, errors.Is(err, ErrReadOnly))
+	_, err = db.Exec("SELECT * FROM users")
+	assert.NoError(t, err)
+
+	hook.SetReadOnly(false)
+	_, err = db.Exec("INSERT INTO users VALUES (1)")
+	assert.NoError(t, err)
+}
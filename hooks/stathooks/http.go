@@ -0,0 +1,145 @@
+package stathooks
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HandlerOption configures the handler returned by Handler.
+type HandlerOption func(*handler)
+
+// WithReset makes POST requests to the handler reset the statistics, and adds
+// a reset button to the HTML report. Requests coming from another origin are
+// rejected, but the handler doesn't authenticate anyone: only mount it where
+// the callers allowed to wipe the statistics can reach it.
+func WithReset() HandlerOption {
+	return func(h *handler) {
+		h.reset = true
+	}
+}
+
+type handler struct {
+	hook  *Hook
+	reset bool
+}
+
+// Handler returns an http.Handler serving the statistics of h as an HTML
+// table, or as JSON if the format=json query parameter is given or the
+// request accepts application/json. Stats are sorted by total time, unless
+// the sort parameter names another column: calls, errors, rows, mean, max or
+// p95. It's read-only unless WithReset is given.
+func (h *Hook) Handler(opts ...HandlerOption) http.Handler {
+	hd := &handler{hook: h}
+	for _, opt := range opts {
+		opt(hd)
+	}
+	return hd
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+	case r.Method == http.MethodPost && h.reset:
+		if !sameOrigin(r) {
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+		h.hook.Reset()
+		http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+		return
+	default:
+		allow := "GET, HEAD"
+		if h.reset {
+			allow += ", POST"
+		}
+		w.Header().Set("Allow", allow)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	snap := h.hook.Snapshot()
+	sortBy := r.URL.Query().Get("sort")
+	sortStats(snap.Stats, sortBy)
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(snap); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := struct {
+		Snapshot
+		Reset bool
+	}{snap, h.reset}
+	if err := report.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// sameOrigin reports whether r doesn't come from another site, as told by
+// the Sec-Fetch-Site or Origin headers browsers set.
+func sameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+var report = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Query statistics</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #ddd; text-align: right; }
+td.query { text-align: left; font-family: monospace; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Query statistics</h1>
+<p>Since {{.Since.Format "2006-01-02 15:04:05 MST"}}{{if .Dropped}}, {{.Dropped}} calls dropped{{end}}.</p>
+{{if .Reset}}<form method="post"><button type="submit">Reset</button></form>{{end}}
+<table>
+<tr>
+<th>Query</th>
+<th><a href="?sort=calls">Calls</a></th>
+<th><a href="?sort=errors">Errors</a></th>
+<th><a href="?sort=rows">Rows</a></th>
+<th><a href="?sort=total">Total</a></th>
+<th><a href="?sort=mean">Mean</a></th>
+<th><a href="?sort=max">Max</a></th>
+<th>P50</th>
+<th><a href="?sort=p95">P95</a></th>
+<th>P99</th>
+</tr>
+{{range .Stats}}<tr>
+<td class="query">{{.Query}}</td>
+<td>{{.Calls}}</td>
+<td>{{.Errors}}</td>
+<td>{{.Rows}}</td>
+<td>{{.Total}}</td>
+<td>{{.Mean}}</td>
+<td>{{.Max}}</td>
+<td>{{.P50}}</td>
+<td>{{.P95}}</td>
+<td>{{.P99}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,79 @@
+package stathooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	hook := New()
+	db := sqltest.Open(t, hook)
+	for i := 0; i < 3; i++ {
+		_, err := db.Exec("SELECT 1")
+		require.NoError(t, err)
+	}
+	_, err := db.Exec("SELECT * FROM missing WHERE name = '<b>'")
+	require.Error(t, err)
+
+	handler := hook.Handler()
+
+	t.Run("JSON", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/?format=json&sort=calls", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var snap Snapshot
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snap))
+		require.Len(t, snap.Stats, 2)
+		assert.Equal(t, "select ?", snap.Stats[0].Query)
+		assert.Equal(t, int64(3), snap.Stats[0].Calls)
+		assert.Equal(t, int64(1), snap.Stats[1].Errors)
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", "text/html")
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "<td class=\"query\">select ?</td>")
+		assert.Contains(t, w.Body.String(), "select * from missing where name = ?")
+		assert.NotContains(t, w.Body.String(), "Reset")
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/stats", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code, "resets are disabled by default")
+		assert.NotEmpty(t, hook.Snapshot().Stats)
+
+		handler := hook.Handler(WithReset())
+		w = httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/stats", nil)
+		req.Header.Set("Origin", "https://evil.example")
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotEmpty(t, hook.Snapshot().Stats)
+
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest("POST", "/stats", nil)
+		req.Header.Set("Origin", "http://example.com")
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/stats", w.Header().Get("Location"))
+		assert.Empty(t, hook.Snapshot().Stats)
+	})
+
+	t.Run("Method", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("DELETE", "/", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -0,0 +1,249 @@
+// Package stathooks aggregates statistics about the queries run, grouped by
+// their fingerprint, like pg_stat_statements does.
+package stathooks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/fingerprint"
+)
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithDialect sets the quoting rules used to fingerprint queries.
+func WithDialect(dialect fingerprint.Dialect) Option {
+	return func(h *Hook) {
+		h.dialect = dialect
+	}
+}
+
+// WithMaxQueries bounds the number of distinct queries tracked, it defaults
+// to 1000. Calls to queries seen after the limit is reached are counted as
+// dropped.
+func WithMaxQueries(n int) Option {
+	return func(h *Hook) {
+		h.maxQueries = n
+	}
+}
+
+// WithSamples sets the number of durations kept per query to compute
+// percentiles, they're computed on the most recent calls. It defaults to
+// 1000.
+func WithSamples(n int) Option {
+	return func(h *Hook) {
+		h.samples = n
+	}
+}
+
+// Stat holds the statistics of a query.
+type Stat struct {
+	// Query is the normalized query, and Fingerprint its hash.
+	Query       string `json:"query"`
+	Fingerprint string `json:"fingerprint"`
+
+	Calls  int64 `json:"calls"`
+	Errors int64 `json:"errors"`
+	// Rows is the number of rows returned by queries, or affected by
+	// execs.
+	Rows int64 `json:"rows"`
+
+	Total time.Duration `json:"total_ns"`
+	Mean  time.Duration `json:"mean_ns"`
+	Max   time.Duration `json:"max_ns"`
+	P50   time.Duration `json:"p50_ns"`
+	P95   time.Duration `json:"p95_ns"`
+	P99   time.Duration `json:"p99_ns"`
+}
+
+// Snapshot holds the statistics gathered since the Hook was created or last
+// reset.
+type Snapshot struct {
+	Since time.Time `json:"since"`
+	// Stats are sorted by Total, in descending order.
+	Stats []Stat `json:"stats"`
+	// Dropped is the number of calls not tracked because of
+	// WithMaxQueries.
+	Dropped int64 `json:"dropped"`
+}
+
+// stat holds the running statistics of a query.
+type stat struct {
+	Stat
+	// durations is a ring buffer of the last durations, next is the index
+	// of the next one.
+	durations []time.Duration
+	next      int
+}
+
+type Hook struct {
+	dialect    fingerprint.Dialect
+	maxQueries int
+	samples    int
+
+	mu      sync.Mutex
+	stats   map[uint64]*stat
+	since   time.Time
+	dropped int64
+}
+
+func New(opts ...Option) *Hook {
+	h := &Hook{
+		maxQueries: 1000,
+		samples:    1000,
+		stats:      make(map[uint64]*stat),
+		since:      time.Now(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type startedKey struct{}
+
+func (h *Hook) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	return context.WithValue(ctx, startedKey{}, time.Now()), nil
+}
+
+func (h *Hook) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	var rows int64
+	if result, ok := sqlhooks.ResultFromContext(ctx); ok {
+		rows, _ = result.RowsAffected()
+	}
+	h.record(ctx, query, rows, false)
+	return ctx, nil
+}
+
+func (h *Hook) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	h.record(ctx, query, 0, true)
+	return err
+}
+
+// OnRowsClose adds the rows read to the statistics of query.
+func (h *Hook) OnRowsClose(ctx context.Context, query string, rows int, err error) {
+	f := fingerprint.Of(h.dialect, query)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.stats[f.Hash]; ok {
+		s.Rows += int64(rows)
+	}
+}
+
+func (h *Hook) record(ctx context.Context, query string, rows int64, failed bool) {
+	started, ok := ctx.Value(startedKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	d := time.Since(started)
+	f := fingerprint.Of(h.dialect, query)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.stats[f.Hash]
+	if !ok {
+		if h.maxQueries > 0 && len(h.stats) >= h.maxQueries {
+			h.dropped++
+			return
+		}
+		s = &stat{Stat: Stat{Query: f.Query, Fingerprint: f.String()}}
+		h.stats[f.Hash] = s
+	}
+
+	s.Calls++
+	if failed {
+		s.Errors++
+	}
+	s.Rows += rows
+	s.Total += d
+	if d > s.Max {
+		s.Max = d
+	}
+	if h.samples > 0 {
+		if len(s.durations) < h.samples {
+			s.durations = append(s.durations, d)
+		} else {
+			s.durations[s.next] = d
+			s.next = (s.next + 1) % h.samples
+		}
+	}
+}
+
+// Snapshot returns the statistics gathered so far.
+func (h *Hook) Snapshot() Snapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snap := Snapshot{
+		Since:   h.since,
+		Stats:   make([]Stat, 0, len(h.stats)),
+		Dropped: h.dropped,
+	}
+	for _, s := range h.stats {
+		st := s.Stat
+		st.Mean = st.Total / time.Duration(st.Calls)
+		if len(s.durations) > 0 {
+			sorted := append([]time.Duration(nil), s.durations...)
+			sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+			st.P50 = percentile(sorted, 50)
+			st.P95 = percentile(sorted, 95)
+			st.P99 = percentile(sorted, 99)
+		}
+		snap.Stats = append(snap.Stats, st)
+	}
+	sortStats(snap.Stats, "total")
+	return snap
+}
+
+// Reset discards the statistics gathered so far.
+func (h *Hook) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stats = make(map[uint64]*stat)
+	h.since = time.Now()
+	h.dropped = 0
+}
+
+// percentile returns the p-th percentile of sorted, using the nearest rank
+// method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// sortStats sorts stats by the given column, in descending order. Ties are
+// sorted by query, to keep the order stable.
+func sortStats(stats []Stat, by string) {
+	key := func(s *Stat) int64 {
+		switch by {
+		case "calls":
+			return s.Calls
+		case "errors":
+			return s.Errors
+		case "rows":
+			return s.Rows
+		case "mean":
+			return int64(s.Mean)
+		case "max":
+			return int64(s.Max)
+		case "p95":
+			return int64(s.P95)
+		}
+		return int64(s.Total)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if a, b := key(&stats[i]), key(&stats[j]); a != b {
+			return a > b
+		}
+		return stats[i].Query < stats[j].Query
+	})
+}
@@ -0,0 +1,99 @@
+package stathooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	hook := New()
+	db := sqltest.Open(t, hook)
+
+	_, err := db.Exec("CREATE TABLE users(id int primary key)")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = db.Exec("INSERT INTO users(id) VALUES (?), (?)", 2*i, 2*i+1)
+		require.NoError(t, err)
+	}
+	_, err = db.Exec("INSERT INTO users(id) VALUES (1), (2)")
+	require.Error(t, err)
+
+	for _, id := range []int{0, 1, 2} {
+		rows, err := db.Query("SELECT id FROM users WHERE id >= ?", id)
+		require.NoError(t, err)
+		for rows.Next() {
+		}
+		require.NoError(t, rows.Close())
+	}
+
+	snap := hook.Snapshot()
+	require.Len(t, snap.Stats, 3)
+	stats := make(map[string]Stat)
+	for _, s := range snap.Stats {
+		stats[s.Query] = s
+		assert.Equal(t, s.Total/time.Duration(s.Calls), s.Mean)
+		assert.True(t, s.P50 <= s.P95 && s.P95 <= s.P99 && s.P99 <= s.Max, s.Query)
+		assert.Len(t, s.Fingerprint, 16)
+	}
+	for i := 1; i < len(snap.Stats); i++ {
+		assert.True(t, snap.Stats[i-1].Total >= snap.Stats[i].Total, "stats must be sorted by total time")
+	}
+
+	insert := stats["insert into users(id) values(?)"]
+	assert.Equal(t, int64(4), insert.Calls)
+	assert.Equal(t, int64(1), insert.Errors)
+	assert.Equal(t, int64(6), insert.Rows)
+
+	query := stats["select id from users where id >= ?"]
+	assert.Equal(t, int64(3), query.Calls)
+	assert.Equal(t, int64(0), query.Errors)
+	assert.Equal(t, int64(6+5+4), query.Rows)
+
+	hook.Reset()
+	snap = hook.Snapshot()
+	assert.Empty(t, snap.Stats)
+	assert.WithinDuration(t, time.Now(), snap.Since, time.Second)
+}
+
+func TestMaxQueries(t *testing.T) {
+	hook := New(WithMaxQueries(1))
+	db := sqltest.Open(t, hook)
+
+	for i := 0; i < 2; i++ {
+		_, err := db.Exec("SELECT 1")
+		require.NoError(t, err)
+	}
+	_, err := db.Exec("SELECT 'other'")
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT 2")
+	require.NoError(t, err)
+
+	snap := hook.Snapshot()
+	require.Len(t, snap.Stats, 1)
+	assert.Equal(t, int64(4), snap.Stats[0].Calls, "literals are grouped")
+	assert.Equal(t, int64(0), snap.Dropped)
+
+	_, err = db.Exec("SELECT * FROM sqlite_master")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), hook.Snapshot().Dropped)
+}
+
+func TestPercentiles(t *testing.T) {
+	hook := New(WithSamples(100))
+	s := &stat{Stat: Stat{Query: "q", Calls: 1}}
+	hook.stats[1] = s
+	for i := 1; i <= 150; i++ {
+		s.durations = append(s.durations, time.Duration(i))
+	}
+	// only the 100 most recent samples are kept
+	s.durations = s.durations[50:]
+
+	snap := hook.Snapshot()
+	assert.Equal(t, time.Duration(100), snap.Stats[0].P50)
+	assert.Equal(t, time.Duration(145), snap.Stats[0].P95)
+	assert.Equal(t, time.Duration(149), snap.Stats[0].P99)
+}
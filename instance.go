@@ -0,0 +1,57 @@
+package sqlhooks
+
+import (
+	"sort"
+	"strings"
+)
+
+// Instance identifies the database a wrapped driver talks to. It allows hooks
+// to tell apart databases that use the same driver.
+type Instance struct {
+	// Name of the instance, i.e: "primary" or "analytics".
+	Name string
+	// Labels are free-form attributes of the instance, i.e: "db.system" or
+	// "role".
+	Labels map[string]string
+}
+
+// String formats the instance as `name` or `name{key=value,...}`, labels are
+// sorted by key.
+func (i Instance) String() string {
+	if len(i.Labels) == 0 {
+		return i.Name
+	}
+
+	keys := make([]string, 0, len(i.Labels))
+	for k := range i.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(i.Name)
+	b.WriteByte('{')
+	for n, k := range keys {
+		if n > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(i.Labels[k])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// WithInstance attaches an instance name and labels to the wrapped driver.
+// They're available to every hook invocation through InstanceFromContext.
+func WithInstance(name string, labels map[string]string) Option {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+
+	return func(o *options) {
+		o.instance = &Instance{Name: name, Labels: copied}
+	}
+}
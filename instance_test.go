@@ -0,0 +1,63 @@
+package sqlhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceString(t *testing.T) {
+	assert.Equal(t, "primary", Instance{Name: "primary"}.String())
+	assert.Equal(t, "primary{db.system=postgresql,role=rw}", Instance{
+		Name:   "primary",
+		Labels: map[string]string{"role": "rw", "db.system": "postgresql"},
+	}.String())
+}
+
+func TestWithInstance(t *testing.T) {
+	labels := map[string]string{"role": "replica"}
+	hooks := newTestHooks()
+
+	var seen []Instance
+	record := func(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+		i, ok := InstanceFromContext(ctx)
+		assert.True(t, ok, "instance not found in context")
+		seen = append(seen, i)
+		return ctx, nil
+	}
+	hooks.before, hooks.after = record, record
+	hooks.onError = func(ctx context.Context, err error, query string, args ...interface{}) error {
+		_, err2 := record(ctx, query, args...)
+		assert.NoError(t, err2)
+		return err
+	}
+
+	db := openDB(t, hooks, WithInstance("analytics", labels))
+	labels["role"] = "changed"
+
+	_, err := db.Exec("SELECT 1")
+	require.NoError(t, err)
+
+	stmt, err := db.Prepare("SELECT ?")
+	require.NoError(t, err)
+	rows, err := stmt.Query(1)
+	require.NoError(t, err)
+	rows.Close()
+	stmt.Close()
+
+	_, err = db.Query("SELECT * FROM missing")
+	require.Error(t, err)
+
+	require.Len(t, seen, 6)
+	for _, i := range seen {
+		assert.Equal(t, "analytics", i.Name)
+		assert.Equal(t, map[string]string{"role": "replica"}, i.Labels)
+	}
+}
+
+func TestInstanceFromContextMissing(t *testing.T) {
+	_, ok := InstanceFromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,65 @@
+// Package callsite finds the code that ran a statement in the call stack of
+// a hook, skipping the frames of database/sql, sqlhooks and the packages
+// running statements on behalf of others, like query builders.
+package callsite
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Skip holds the prefixes of the functions, qualified by their import path,
+// that don't count as call sites.
+type Skip []string
+
+// New returns the Skip of database/sql, sqlhooks, the runtime and prefixes.
+func New(prefixes ...string) Skip {
+	return append(Skip{"database/sql.", "github.com/qustavo/sqlhooks/v2.", "runtime."}, prefixes...)
+}
+
+// Caller returns the file:line location of the first function of pcs, as
+// filled by runtime.Callers, that isn't skipped. It's empty if there's none.
+func (s Skip) Caller(pcs []uintptr) string {
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if !s.skipped(frame.Function) {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// Stack returns the location of the first function of pcs that isn't
+// skipped, like Caller, and the stack starting at it. It's slower than
+// Caller.
+func (s Skip) Stack(pcs []uintptr) (caller, stack string) {
+	frames := runtime.CallersFrames(pcs)
+
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		if caller != "" || !s.skipped(frame.Function) {
+			if caller == "" {
+				caller = frame.File + ":" + strconv.Itoa(frame.Line)
+			}
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			return caller, b.String()
+		}
+	}
+}
+
+func (s Skip) skipped(function string) bool {
+	for _, prefix := range s {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
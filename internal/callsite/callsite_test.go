@@ -0,0 +1,38 @@
+package callsite
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// callers returns the program counters of the stack of its caller.
+func callers() []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(1, pcs)]
+}
+
+func TestCaller(t *testing.T) {
+	pcs := callers()
+	caller := New().Caller(pcs)
+	assert.Equal(t, "callsite_test.go:15", filepath.Base(caller))
+
+	caller = New("github.com/qustavo/sqlhooks/v2/internal/callsite.callers").Caller(pcs)
+	assert.Equal(t, "callsite_test.go:19", filepath.Base(caller))
+
+	assert.Empty(t, Skip{""}.Caller(pcs))
+}
+
+func TestStack(t *testing.T) {
+	pcs := callers()
+	caller, stack := New("github.com/qustavo/sqlhooks/v2/internal/callsite.callers").Stack(pcs)
+	assert.Equal(t, New("github.com/qustavo/sqlhooks/v2/internal/callsite.callers").Caller(pcs), caller)
+	assert.True(t, strings.HasPrefix(stack, "github.com/qustavo/sqlhooks/v2/internal/callsite.TestStack\n\t"+caller+"\n"), stack)
+
+	caller, stack = Skip{""}.Stack(pcs)
+	assert.Empty(t, caller)
+	assert.Empty(t, stack)
+}
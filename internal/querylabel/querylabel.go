@@ -0,0 +1,97 @@
+// Package querylabel holds what the metrics hooks share to label queries
+// while keeping the cardinality of the labels bounded.
+package querylabel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/qustavo/sqlhooks/v2/hooks/errclass"
+	"github.com/qustavo/sqlhooks/v2/internal/sqlscan"
+)
+
+// Other replaces the label values exceeding the limits.
+const Other = "other"
+
+type nameKey struct{}
+
+// ContextWithName returns a copy of ctx naming the queries run with it.
+func ContextWithName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, nameKey{}, name)
+}
+
+// NameFromContext returns the name given with ContextWithName.
+func NameFromContext(ctx context.Context, query string) string {
+	name, _ := ctx.Value(nameKey{}).(string)
+	return name
+}
+
+// Names bounds the number of distinct query names.
+type Names struct {
+	max int
+
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+// NewNames returns Names allowing up to max distinct names, 0 means no
+// limit.
+func NewNames(max int) *Names {
+	return &Names{max: max, seen: make(map[string]struct{})}
+}
+
+// Limit returns name, or Other if it would exceed the number of distinct
+// names allowed.
+func (n *Names) Limit(name string) string {
+	if n.max <= 0 {
+		return name
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.seen[name]; ok {
+		return name
+	}
+	if len(n.seen) >= n.max {
+		return Other
+	}
+	n.seen[name] = struct{}{}
+	return name
+}
+
+// operations are the statement keywords used as operation label, others are
+// labeled Other.
+var operations = map[string]bool{
+	"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true,
+	"REPLACE": true, "MERGE": true, "WITH": true, "CALL": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true,
+	"BEGIN": true, "COMMIT": true, "ROLLBACK": true, "SAVEPOINT": true,
+	"RELEASE": true, "SET": true, "SHOW": true, "EXPLAIN": true,
+	"PRAGMA": true, "COPY": true,
+}
+
+// Operation returns the keyword query starts with in upper case, or Other if
+// it's not a known statement keyword.
+func Operation(query string) string {
+	if op := sqlscan.Keyword(sqlscan.Any, query); operations[op] {
+		return op
+	}
+	return Other
+}
+
+// ErrorClass returns the errclass category of err with underscores instead
+// of spaces, "canceled", "timeout" or Other.
+func ErrorClass(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	if class := errclass.Classify(err); class != nil {
+		return strings.ReplaceAll(class.Error(), " ", "_")
+	}
+	return Other
+}
@@ -0,0 +1,44 @@
+package querylabel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestName(t *testing.T) {
+	ctx := ContextWithName(context.Background(), "users")
+	assert.Equal(t, "users", NameFromContext(ctx, "SELECT 1"))
+	assert.Equal(t, "", NameFromContext(context.Background(), "SELECT 1"))
+}
+
+func TestNames(t *testing.T) {
+	names := NewNames(2)
+	assert.Equal(t, "a", names.Limit("a"))
+	assert.Equal(t, "b", names.Limit("b"))
+	assert.Equal(t, Other, names.Limit("c"))
+	assert.Equal(t, "a", names.Limit("a"))
+
+	assert.Equal(t, "c", NewNames(0).Limit("c"))
+}
+
+func TestOperation(t *testing.T) {
+	for query, want := range map[string]string{
+		"SELECT 1":                             "SELECT",
+		"/* app=api */ update users SET x = 1": "UPDATE",
+		"-- comment\nDELETE FROM users":        "DELETE",
+		"VACUUM":                               Other,
+		"":                                     Other,
+	} {
+		assert.Equal(t, want, Operation(query), query)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	assert.Equal(t, "canceled", ErrorClass(context.Canceled))
+	assert.Equal(t, "timeout", ErrorClass(context.DeadlineExceeded))
+	assert.Equal(t, "unique_violation", ErrorClass(&pq.Error{Code: "23505"}))
+	assert.Equal(t, Other, ErrorClass(assert.AnError))
+}
@@ -0,0 +1,83 @@
+// Package skipdriver provides a database/sql driver for tests whose
+// connections return driver.ErrSkip from ExecContext and QueryContext, so
+// database/sql falls back to prepared statements, like go-sql-driver/mysql
+// does for queries with arguments by default.
+package skipdriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"sync"
+)
+
+// Driver opens connections that skip every statement run without preparing
+// it first. Prepared statements succeed, affecting one row or returning none.
+type Driver struct {
+	// BadConns is the number of connections left to open broken: they fail
+	// every statement with driver.ErrBadConn.
+	BadConns int
+
+	mu sync.Mutex
+}
+
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	bad := d.BadConns > 0
+	if bad {
+		d.BadConns--
+	}
+	return conn{bad: bad}, nil
+}
+
+type conn struct {
+	bad bool
+}
+
+func (c conn) err() error {
+	if c.bad {
+		return driver.ErrBadConn
+	}
+	return driver.ErrSkip
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return nil, c.err()
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return nil, c.err()
+}
+
+func (c conn) Prepare(query string) (driver.Stmt, error) {
+	if c.bad {
+		return nil, driver.ErrBadConn
+	}
+	return stmt{}, nil
+}
+
+func (conn) Close() error              { return nil }
+func (conn) Begin() (driver.Tx, error) { return tx{}, nil }
+func (conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return tx{}, nil
+}
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type stmt struct{}
+
+func (stmt) Close() error                                    { return nil }
+func (stmt) NumInput() int                                   { return -1 }
+func (stmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (stmt) Query(args []driver.Value) (driver.Rows, error)  { return rows{}, nil }
+
+type rows struct{}
+
+func (rows) Columns() []string              { return []string{"n"} }
+func (rows) Close() error                   { return nil }
+func (rows) Next(dest []driver.Value) error { return io.EOF }
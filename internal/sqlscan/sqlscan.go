@@ -0,0 +1,246 @@
+// Package sqlscan splits SQL statements into tokens, following the quoting
+// rules of MySQL, Postgres and SQLite.
+package sqlscan
+
+import "strings"
+
+// Dialect selects the quoting rules. Any accepts the syntax of every dialect.
+type Dialect int
+
+const (
+	Any Dialect = iota
+	MySQL
+	Postgres
+	SQLite
+)
+
+// Kind is the kind of a Token.
+type Kind int
+
+const (
+	Other       Kind = iota // operators and punctuation
+	Space                   // whitespace
+	Number                  // numeric literals
+	Comment                 // -- and /* */ comments
+	String                  // string literals
+	Word                    // keywords and identifiers
+	Placeholder             // argument placeholders
+)
+
+// Token is a piece of a statement.
+type Token struct {
+	Kind Kind
+	Text string
+	// Name is the unquoted identifier of Word tokens.
+	Name string
+	// Quoted is true for quoted identifiers.
+	Quoted bool
+}
+
+// Scan returns the tokens of query, concatenating their Text gives query
+// back.
+func Scan(dialect Dialect, query string) []Token {
+	var tokens []Token
+	for i := 0; i < len(query); {
+		tok := token(dialect, query, i)
+		tokens = append(tokens, tok)
+		i += len(tok.Text)
+	}
+	return tokens
+}
+
+// Keyword returns the keyword query starts with in upper case, i.e: "SELECT",
+// skipping spaces, comments and opening parentheses. It's empty if query
+// doesn't start with a keyword.
+func Keyword(dialect Dialect, query string) string {
+	for i := 0; i < len(query); {
+		tok := token(dialect, query, i)
+		switch {
+		case tok.Kind == Space, tok.Kind == Comment, tok.Text == "(":
+			i += len(tok.Text)
+		case tok.Kind == Word && !tok.Quoted:
+			return strings.ToUpper(tok.Text)
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// token returns the token starting at query[i].
+func token(dialect Dialect, query string, i int) Token {
+	c := query[i]
+	switch {
+	case c == '\'' || (c == '"' && dialect == MySQL):
+		return Token{Kind: String, Text: query[i:quoted(query, i, c, dialect == MySQL)]}
+	case (c == 'E' || c == 'e') && dialect != MySQL && strings.HasPrefix(query[i+1:], "'"):
+		// Postgres escape strings
+		return Token{Kind: String, Text: query[i:quoted(query, i+1, '\'', true)]}
+	case (c == 'X' || c == 'x') && strings.HasPrefix(query[i+1:], "'"):
+		// blob literals
+		return Token{Kind: String, Text: query[i:quoted(query, i+1, '\'', false)]}
+	case c == '"' || (c == '`' && dialect != Postgres):
+		return identifier(query, i, c, c)
+	case c == '[' && (dialect == SQLite || dialect == Any):
+		return identifier(query, i, '[', ']')
+	case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#' && dialect == MySQL:
+		end := strings.IndexByte(query[i:], '\n')
+		if end == -1 {
+			end = len(query) - i
+		}
+		return Token{Kind: Comment, Text: query[i : i+end]}
+	case c == '/' && strings.HasPrefix(query[i:], "/*"):
+		end := strings.Index(query[i+2:], "*/")
+		if end == -1 {
+			return Token{Kind: Comment, Text: query[i:]}
+		}
+		return Token{Kind: Comment, Text: query[i : i+2+end+2]}
+	case c == '$' && dialect != MySQL && dollarQuoted(query, i) != i:
+		return Token{Kind: String, Text: query[i:dollarQuoted(query, i)]}
+	case c == ':' && strings.HasPrefix(query[i:], "::"):
+		// Postgres type cast
+		return Token{Kind: Other, Text: "::"}
+	case isSpace(c):
+		end := i
+		for end < len(query) && isSpace(query[end]) {
+			end++
+		}
+		return Token{Kind: Space, Text: query[i:end]}
+	case isDigit(c) || c == '.' && i+1 < len(query) && isDigit(query[i+1]):
+		return Token{Kind: Number, Text: query[i : i+number(query[i:])]}
+	case isLetter(c):
+		end := i + word(query[i:])
+		return Token{Kind: Word, Text: query[i:end], Name: query[i:end]}
+	case strings.IndexByte("<>=!|", c) != -1:
+		end := i
+		for end < len(query) && strings.IndexByte("<>=!|", query[end]) != -1 {
+			end++
+		}
+		return Token{Kind: Other, Text: query[i:end]}
+	}
+
+	if end := placeholder(dialect, query, i); end != i {
+		return Token{Kind: Placeholder, Text: query[i:end]}
+	}
+	return Token{Kind: Other, Text: query[i : i+1]}
+}
+
+func identifier(query string, i int, open, close byte) Token {
+	end := quoted(query, i, close, false)
+	text := query[i:end]
+	name := strings.TrimPrefix(text, string(open))
+	name = strings.TrimSuffix(name, string(close))
+	if open == close {
+		name = strings.ReplaceAll(name, string(open)+string(open), string(open))
+	}
+	return Token{Kind: Word, Text: text, Name: name, Quoted: true}
+}
+
+// placeholder returns the end of the placeholder starting at query[i], or i
+// if there's none.
+func placeholder(dialect Dialect, query string, i int) int {
+	c := query[i]
+	switch {
+	case c == '?' && dialect != Postgres:
+		if dialect == MySQL {
+			return i + 1
+		}
+		return i + 1 + digits(query[i+1:])
+	case c == '$' && dialect != MySQL && digits(query[i+1:]) > 0:
+		return i + 1 + digits(query[i+1:])
+	case (c == ':' || c == '@' || c == '$') && (dialect == SQLite || dialect == Any):
+		if n := word(query[i+1:]); n > 0 {
+			return i + 1 + n
+		}
+	}
+	return i
+}
+
+// quoted returns the end of the quoted string or identifier starting at
+// query[i], closed by q. Quotes are escaped by doubling them, and by
+// backslashes if backslash is true.
+func quoted(query string, i int, q byte, backslash bool) int {
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if backslash {
+				j++
+			}
+		case q:
+			if j+1 < len(query) && query[j+1] == q && q != ']' {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+// dollarQuoted returns the end of the Postgres dollar quoted string starting
+// at query[i], or i if there's none.
+func dollarQuoted(query string, i int) int {
+	end := strings.IndexByte(query[i+1:], '$')
+	if end == -1 {
+		return i
+	}
+	tag := query[i : i+end+2]
+	if word(tag[1:len(tag)-1]) != len(tag)-2 || (len(tag) > 2 && isDigit(tag[1])) {
+		return i
+	}
+
+	close := strings.Index(query[i+len(tag):], tag)
+	if close == -1 {
+		return len(query)
+	}
+	return i + len(tag) + close + len(tag)
+}
+
+// number returns the length of the numeric literal s starts with, including
+// hexadecimal literals, decimals and exponents.
+func number(s string) int {
+	if len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		n := 2
+		for n < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[n]) != -1 {
+			n++
+		}
+		return n
+	}
+
+	n := digits(s)
+	if n < len(s) && s[n] == '.' {
+		n += 1 + digits(s[n+1:])
+	}
+	if n < len(s) && (s[n] == 'e' || s[n] == 'E') {
+		m := n + 1
+		if m < len(s) && (s[m] == '+' || s[m] == '-') {
+			m++
+		}
+		if d := digits(s[m:]); d > 0 {
+			n = m + d
+		}
+	}
+	return n
+}
+
+func digits(s string) int {
+	n := 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	return n
+}
+
+func word(s string) int {
+	n := 0
+	for n < len(s) && (isDigit(s[n]) || isLetter(s[n]) || s[n] == '$' && n > 0) {
+		n++
+	}
+	return n
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' }
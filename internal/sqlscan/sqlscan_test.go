@@ -0,0 +1,71 @@
+package sqlscan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func kinds(tokens []Token) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		if tok.Kind == Space {
+			continue
+		}
+		b.WriteString("OSNCSWP"[tok.Kind : tok.Kind+1])
+	}
+	return b.String()
+}
+
+func TestScan(t *testing.T) {
+	for _, it := range []struct {
+		dialect Dialect
+		query   string
+		kinds   string
+	}{
+		{Any, "SELECT * FROM t WHERE a = ? AND b = $1 AND c = :c", "WOWWWWOPWWOPWWOP"},
+		{Any, "SELECT 'it''s', 1.5e3, 0xff, .5 -- done", "WSONONONC"},
+		{MySQL, `SELECT "a\"b", 'c\'d', ` + "`t`" + ` # done`, "WSOSOWC"},
+		{Postgres, "SELECT $$a'b$$, E'c\\'d', $tag$x$tag$, a::int", "WSOSOSOWOW"},
+		{Postgres, `SELECT "a""b" FROM t WHERE x = $2`, "WWWWWWOP"},
+		{SQLite, "SELECT [a b], x'00' FROM t /* c */", "WWOSWWC"},
+	} {
+		tokens := Scan(it.dialect, it.query)
+		assert.Equal(t, it.kinds, kinds(tokens), it.query)
+
+		var b strings.Builder
+		for _, tok := range tokens {
+			b.WriteString(tok.Text)
+		}
+		assert.Equal(t, it.query, b.String())
+	}
+}
+
+func TestScanIdentifiers(t *testing.T) {
+	tokens := Scan(Any, "`a``b` \"c\"\"d\" [e f] g")
+	var names []string
+	for _, tok := range tokens {
+		if tok.Kind == Word {
+			names = append(names, tok.Name)
+			assert.Equal(t, tok.Name != "g", tok.Quoted)
+		}
+	}
+	assert.Equal(t, []string{"a`b", `c"d`, "e f", "g"}, names)
+}
+
+func TestKeyword(t *testing.T) {
+	for query, want := range map[string]string{
+		"SELECT 1":                             "SELECT",
+		"  select\n1":                          "SELECT",
+		"-- comment\nUPDATE t SET a = 1":       "UPDATE",
+		"/* c */ (SELECT 1) UNION (SELECT 2)":  "SELECT",
+		"with x as (select 1) select * from x": "WITH",
+		"/* unterminated":                      "",
+		`"quoted" identifier`:                  "",
+		"":                                     "",
+	} {
+		assert.Equal(t, want, Keyword(Any, query), query)
+	}
+	assert.Equal(t, "SELECT", Keyword(MySQL, "# comment\nSELECT 1"))
+}
@@ -0,0 +1,46 @@
+// Package sqltest opens databases instrumented by hooks, for the tests of the
+// hooks packages.
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/qustavo/sqlhooks/v2"
+)
+
+// Open returns an in-memory SQLite database instrumented by hooks, which is
+// closed when t ends. It's limited to a single connection, so that every
+// statement sees the same database.
+func Open(t testing.TB, hooks sqlhooks.Hooks, opts ...sqlhooks.Option) *sql.DB {
+	return OpenDriver(t, &sqlite3.SQLiteDriver{}, hooks, opts...)
+}
+
+// OpenDSN is like Open, opening the SQLite database named by dsn instead.
+func OpenDSN(t testing.TB, dsn string, hooks sqlhooks.Hooks, opts ...sqlhooks.Option) *sql.DB {
+	return open(t, &sqlite3.SQLiteDriver{}, dsn, hooks, opts...)
+}
+
+// OpenDriver is like Open, opening the database through drv instead.
+func OpenDriver(t testing.TB, drv driver.Driver, hooks sqlhooks.Hooks, opts ...sqlhooks.Option) *sql.DB {
+	return open(t, drv, ":memory:", hooks, opts...)
+}
+
+func open(t testing.TB, drv driver.Driver, dsn string, hooks sqlhooks.Hooks, opts ...sqlhooks.Option) *sql.DB {
+	db := sql.OpenDB(connector{sqlhooks.Wrap(drv, hooks, opts...), dsn})
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// connector opens a *sql.DB without registering its driver.
+type connector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c connector) Driver() driver.Driver                        { return c.driver }
@@ -0,0 +1,22 @@
+package sqlhooks
+
+// Option configures the driver returned by Wrap.
+type Option func(*options)
+
+type options struct {
+	queryError bool
+	redact     func(query string) string
+	instance   *Instance
+	retry      *RetryPolicy
+}
+
+// WithQueryError makes the instrumented driver return every driver failure
+// as a *QueryError, which carries the statement that caused it.
+// If redact is not nil, it is applied to the query before storing it in the
+// error, so that sensitive literals can be removed.
+func WithQueryError(redact func(query string) string) Option {
+	return func(o *options) {
+		o.queryError = true
+		o.redact = redact
+	}
+}
@@ -0,0 +1,110 @@
+package sqlhooks
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures the retries enabled by WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a statement runs,
+	// including the first one. It defaults to 3.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it doubles on every
+	// attempt up to MaxDelay. Delays are randomized between half and the
+	// whole of their value. They default to 10ms and 1s.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Retryable reports whether err is transient and the statement can be
+	// run again, i.e: errclass.IsTransient. It defaults to
+	// DefaultRetryable, which never retries the errors of lib/pq,
+	// go-sql-driver/mysql or mattn/go-sqlite3: set it when using them.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryable reports whether err, or any error it wraps, implements
+// a Temporary method returning true.
+// The errors of lib/pq, go-sql-driver/mysql and mattn/go-sqlite3 have no such
+// method, so it never retries their failures, errclass.IsTransient classifies
+// them instead.
+func DefaultRetryable(err error) bool {
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
+
+// WithRetry makes the instrumented driver run again the statements failing
+// with a transient error, as classified by the policy.
+// Only the statements run outside of transactions through DB.Exec and
+// DB.Query, and their context variants, are retried. Prepared statements
+// are not.
+// Hooks are called for every attempt, see AttemptFromContext, and WillRetry
+// reports true in OnError when the failure is going to be retried. Retries
+// stop when the context is done, or when its deadline would be reached
+// before the next attempt, the last failure is returned then.
+func WithRetry(policy RetryPolicy) Option {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = 10 * time.Millisecond
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = time.Second
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = DefaultRetryable
+	}
+
+	return func(o *options) {
+		o.retry = &policy
+	}
+}
+
+// AttemptFromContext returns the attempt number of the statement hooks are
+// being called for, starting at 1. It's always 1 unless WithRetry is used.
+func AttemptFromContext(ctx context.Context) int {
+	if attempt := callFromContext(ctx).attempt; attempt > 0 {
+		return attempt
+	}
+	return 1
+}
+
+// retryDelay returns how long to wait before running again a statement
+// that failed with err on the given attempt. It's 0 if the statement must
+// not be retried.
+func (conn *Conn) retryDelay(ctx context.Context, err error, attempt int) time.Duration {
+	if conn.opts == nil || conn.opts.retry == nil || conn.tx != nil {
+		return 0
+	}
+	policy := conn.opts.retry
+	if attempt >= policy.MaxAttempts || errors.Is(err, driver.ErrBadConn) || !policy.Retryable(err) {
+		return 0
+	}
+
+	delay := policy.BaseDelay << (attempt - 1)
+	if delay > policy.MaxDelay || delay <= 0 {
+		delay = policy.MaxDelay
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return 0
+	}
+	return delay
+}
+
+// sleep waits for d, it returns false if ctx is done before.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
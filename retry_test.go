@@ -0,0 +1,171 @@
+package sqlhooks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type temporaryErr struct{ temporary bool }
+
+func (e temporaryErr) Error() string   { return "temporary" }
+func (e temporaryErr) Temporary() bool { return e.temporary }
+
+func TestDefaultRetryable(t *testing.T) {
+	assert.True(t, DefaultRetryable(temporaryErr{true}))
+	assert.True(t, DefaultRetryable(fmt.Errorf("wrapped: %w", temporaryErr{true})))
+	assert.False(t, DefaultRetryable(temporaryErr{false}))
+	assert.False(t, DefaultRetryable(errors.New("permanent")))
+}
+
+// attemptHooks records the attempt numbers hooks are called with.
+type attemptHooks struct {
+	calls []string
+}
+
+func (h *attemptHooks) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	h.calls = append(h.calls, fmt.Sprintf("before %d", AttemptFromContext(ctx)))
+	return ctx, nil
+}
+
+func (h *attemptHooks) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	h.calls = append(h.calls, fmt.Sprintf("after %d", AttemptFromContext(ctx)))
+	return ctx, nil
+}
+
+func (h *attemptHooks) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	call := fmt.Sprintf("error %d", AttemptFromContext(ctx))
+	if WillRetry(ctx) {
+		call += " (retry)"
+	}
+	h.calls = append(h.calls, call)
+	return err
+}
+
+func TestWithRetry(t *testing.T) {
+	var retried []error
+	hooks := &attemptHooks{}
+	db := openDB(t, hooks, WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		Retryable: func(err error) bool {
+			retried = append(retried, err)
+			return true
+		},
+	}))
+
+	_, err := db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"before 1", "error 1 (retry)",
+		"before 2", "error 2 (retry)",
+		"before 3", "error 3",
+	}, hooks.calls)
+	assert.Len(t, retried, 2, "the last attempt is not classified")
+
+	hooks.calls = nil
+	rows, err := db.Query("SELECT 1")
+	require.NoError(t, err)
+	rows.Close()
+	assert.Equal(t, []string{"before 1", "after 1"}, hooks.calls)
+}
+
+func TestWithRetryRecovers(t *testing.T) {
+	dsn := "file:retry-recovers?mode=memory&cache=shared"
+	other, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	defer other.Close()
+
+	hooks := &attemptHooks{}
+	db := openDSN(t, dsn, hooks, WithRetry(RetryPolicy{
+		BaseDelay: time.Millisecond,
+		Retryable: func(err error) bool {
+			// the table is created before the next attempt
+			_, cerr := other.Exec("CREATE TABLE IF NOT EXISTS late(id int)")
+			require.NoError(t, cerr)
+			return true
+		},
+	}))
+
+	rows, err := db.Query("SELECT * FROM late")
+	require.NoError(t, err)
+	rows.Close()
+	assert.Equal(t, []string{"before 1", "error 1 (retry)", "before 2", "after 2"}, hooks.calls)
+}
+
+func TestWithRetryClassification(t *testing.T) {
+	hooks := &attemptHooks{}
+	db := openDB(t, hooks, WithRetry(RetryPolicy{BaseDelay: time.Millisecond}))
+
+	_, err := db.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+	assert.Equal(t, []string{"before 1", "error 1"}, hooks.calls, "SQLite errors are not temporary")
+}
+
+func TestWithRetryTransactions(t *testing.T) {
+	hooks := &attemptHooks{}
+	db := openDB(t, hooks, WithRetry(RetryPolicy{
+		BaseDelay: time.Millisecond,
+		Retryable: func(error) bool { return true },
+	}))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SELECT * FROM missing")
+	require.Error(t, err)
+	assert.Equal(t, []string{"before 1", "error 1"}, hooks.calls)
+}
+
+func TestWithRetryDeadline(t *testing.T) {
+	hooks := &attemptHooks{}
+	db := openDB(t, hooks, WithRetry(RetryPolicy{
+		BaseDelay: time.Hour,
+		MaxDelay:  time.Hour,
+		Retryable: func(error) bool { return true },
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err := db.ExecContext(ctx, "SELECT * FROM missing")
+	require.Error(t, err)
+	assert.Equal(t, []string{"before 1", "error 1"}, hooks.calls, "the deadline would be exceeded before retrying")
+
+	hooks.calls = nil
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err = db.ExecContext(ctx, "SELECT * FROM missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no such table")
+	assert.Equal(t, []string{"before 1", "error 1 (retry)"}, hooks.calls, "retries stop when the context is done")
+}
+
+func TestRetryDelay(t *testing.T) {
+	conn := &Conn{opts: &options{}}
+	WithRetry(RetryPolicy{
+		MaxAttempts: 10,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    time.Second,
+		Retryable:   func(error) bool { return true },
+	})(conn.opts)
+
+	ctx := context.Background()
+	for attempt, max := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		d := conn.retryDelay(ctx, assert.AnError, attempt)
+		assert.True(t, d >= max/2 && d <= max, "attempt %d: %s", attempt, d)
+	}
+	assert.Zero(t, conn.retryDelay(ctx, assert.AnError, 10), "attempts are used up")
+}
@@ -0,0 +1,109 @@
+package sqlhooks
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+)
+
+// OnRowsCloser instances will be called when the rows returned by a query
+// are closed. ctx is the context returned by the After hook, rows is the
+// number of rows read, and err the error that stopped the iteration or
+// closing the rows returned, if any.
+type OnRowsCloser interface {
+	OnRowsClose(ctx context.Context, query string, rows int, err error)
+}
+
+// wrapRows returns rows instrumented for hooks implementing OnRowsCloser.
+func wrapRows(ctx context.Context, hooks Hooks, query string, rows driver.Rows) driver.Rows {
+	h, ok := hooks.(OnRowsCloser)
+	if !ok || rows == nil {
+		return rows
+	}
+	return &Rows{Rows: rows, hook: h, ctx: ctx, query: query}
+}
+
+// Rows implements a database/sql/driver.Rows, and every optional Rows
+// interface, falling back to the behaviour of database/sql when the
+// underlying rows don't implement them.
+type Rows struct {
+	Rows  driver.Rows
+	hook  OnRowsCloser
+	ctx   context.Context
+	query string
+	count int
+	err   error
+}
+
+func (r *Rows) Columns() []string { return r.Rows.Columns() }
+
+func (r *Rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch err {
+	case nil:
+		r.count++
+	case io.EOF:
+	default:
+		r.err = err
+	}
+	return err
+}
+
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	if r.err == nil {
+		r.err = err
+	}
+	r.hook.OnRowsClose(r.ctx, r.query, r.count, r.err)
+	return err
+}
+
+func (r *Rows) HasNextResultSet() bool {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *Rows) NextResultSet() error {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return rs.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *Rows) ColumnTypeDatabaseTypeName(index int) string {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return rs.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *Rows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return rs.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *Rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return rs.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *Rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if rs, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return rs.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,64 @@
+package sqlhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rowsKey struct{}
+
+type closedRows struct {
+	query string
+	rows  int
+	ctx   interface{}
+	err   error
+}
+
+type rowsHooks struct {
+	*testHooks
+	closed []closedRows
+}
+
+func (h *rowsHooks) OnRowsClose(ctx context.Context, query string, rows int, err error) {
+	h.closed = append(h.closed, closedRows{query, rows, ctx.Value(rowsKey{}), err})
+}
+
+func TestOnRowsClose(t *testing.T) {
+	hooks := &rowsHooks{testHooks: newTestHooks()}
+	hooks.after = func(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+		return context.WithValue(ctx, rowsKey{}, "after"), nil
+	}
+
+	db := openDB(t, Compose(hooks))
+
+	_, err := db.Exec("CREATE TABLE t(id int)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO t VALUES (1), (2), (3)")
+	require.NoError(t, err)
+	assert.Empty(t, hooks.closed, "exec has no rows")
+
+	rows, err := db.Query("SELECT id FROM t")
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Close())
+
+	stmt, err := db.Prepare("SELECT id FROM t WHERE id > ?")
+	require.NoError(t, err)
+	defer stmt.Close()
+	rows, err = stmt.Query(1)
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, "int", types[0].DatabaseTypeName())
+	require.NoError(t, rows.Close())
+
+	assert.Equal(t, []closedRows{
+		{"SELECT id FROM t", 3, "after", nil},
+		{"SELECT id FROM t WHERE id > ?", 1, "after", nil},
+	}, hooks.closed)
+}
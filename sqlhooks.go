@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql/driver"
 	"errors"
+	"sync/atomic"
+	"time"
 )
 
 // Hook is the hook callback signature
@@ -23,12 +25,57 @@ type OnErrorer interface {
 	OnError(ctx context.Context, err error, query string, args ...interface{}) error
 }
 
-func handlerErr(ctx context.Context, hooks Hooks, err error, query string, args ...interface{}) error {
+// OnSkipper instances will be called instead of After or OnError when the
+// driver returns driver.ErrSkip. In that case database/sql runs the statement
+// again through a prepared statement, which calls the hooks once more, so
+// OnSkip is the chance to discard whatever Before has started.
+type OnSkipper interface {
+	OnSkip(ctx context.Context, query string, args ...interface{})
+}
+
+type (
+	retryKey   struct{}
+	badConnKey struct{}
+)
+
+// WillRetry reports whether the failure OnError is being called for is
+// going to be retried because of WithRetry, so hooks may decide not to count
+// it as a failure.
+func WillRetry(ctx context.Context) bool {
+	retry, _ := ctx.Value(retryKey{}).(bool)
+	return retry
+}
+
+// BadConn reports whether OnError is being called because the driver
+// returned driver.ErrBadConn. database/sql usually runs the statement again
+// on another connection, but not on a *sql.Conn, inside a transaction or
+// once it has run out of attempts, and a driver can't tell these cases
+// apart. Hooks that skip these failures should count the error database/sql
+// ends up returning instead.
+func BadConn(ctx context.Context) bool {
+	bad, _ := ctx.Value(badConnKey{}).(bool)
+	return bad
+}
+
+func handlerSkip(ctx context.Context, hooks Hooks, query string, args ...interface{}) {
+	if h, ok := hooks.(OnSkipper); ok {
+		h.OnSkip(ctx, query, args...)
+	}
+}
+
+func handlerErr(ctx context.Context, hooks Hooks, err error, retry bool, query string, args ...interface{}) error {
 	h, ok := hooks.(OnErrorer)
 	if !ok {
 		return err
 	}
 
+	if retry {
+		ctx = context.WithValue(ctx, retryKey{}, true)
+	}
+	if errors.Is(err, driver.ErrBadConn) {
+		ctx = context.WithValue(ctx, badConnKey{}, true)
+	}
+
 	if err := h.OnError(ctx, err, query, args...); err != nil {
 		return err
 	}
@@ -36,10 +83,14 @@ func handlerErr(ctx context.Context, hooks Hooks, err error, query string, args
 	return err
 }
 
+// connID is incremented every time a connection is opened by any Driver.
+var connID uint64
+
 // Driver implements a database/sql/driver.Driver
 type Driver struct {
 	driver.Driver
 	hooks Hooks
+	opts  options
 }
 
 // Open opens a connection
@@ -54,7 +105,12 @@ func (drv *Driver) Open(name string) (driver.Conn, error) {
 		return nil, errors.New("driver must implement driver.ConnBeginTx")
 	}
 
-	wrapped := &Conn{conn, drv.hooks}
+	wrapped := &Conn{
+		Conn:  conn,
+		hooks: drv.hooks,
+		opts:  &drv.opts,
+		id:    atomic.AddUint64(&connID, 1),
+	}
 	if isExecer(conn) && isQueryer(conn) && isSessionResetter(conn) {
 		return &ExecerQueryerContextWithSessionResetter{wrapped,
 			&ExecerContext{wrapped}, &QueryerContext{wrapped},
@@ -78,6 +134,36 @@ func (drv *Driver) Open(name string) (driver.Conn, error) {
 type Conn struct {
 	Conn  driver.Conn
 	hooks Hooks
+	opts  *options
+	id    uint64
+	// tx is the context of the running transaction, if any.
+	tx context.Context
+}
+
+// ID returns an identifier of the connection, unique within the process.
+func (conn *Conn) ID() uint64 { return conn.id }
+
+// wrapErr returns err as a *QueryError when the driver was wrapped using
+// WithQueryError, otherwise err is returned unchanged.
+func (conn *Conn) wrapErr(err error, op Op, query string, nargs int, elapsed time.Duration) error {
+	// driver.ErrSkip and driver.ErrBadConn are signals to database/sql
+	// rather than failures, they're never wrapped.
+	if err == nil || err == driver.ErrSkip || err == driver.ErrBadConn || conn.opts == nil || !conn.opts.queryError {
+		return err
+	}
+
+	if conn.opts.redact != nil {
+		query = conn.opts.redact(query)
+	}
+
+	return &QueryError{
+		Op:       op,
+		Query:    query,
+		NumArgs:  nargs,
+		Duration: elapsed,
+		ConnID:   conn.id,
+		Err:      err,
+	}
 }
 
 func (conn *Conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
@@ -96,15 +182,12 @@ func (conn *Conn) PrepareContext(ctx context.Context, query string) (driver.Stmt
 		return stmt, err
 	}
 
-	return &Stmt{stmt, conn.hooks, query}, nil
+	return &Stmt{Stmt: stmt, hooks: conn.hooks, query: query, conn: conn}, nil
 }
 
 func (conn *Conn) Prepare(query string) (driver.Stmt, error) { return conn.Conn.Prepare(query) }
 func (conn *Conn) Close() error                              { return conn.Conn.Close() }
 func (conn *Conn) Begin() (driver.Tx, error)                 { return conn.Conn.Begin() }
-func (conn *Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
-	return conn.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
-}
 
 // ExecerContext implements a database/sql.driver.ExecerContext
 type ExecerContext struct {
@@ -139,25 +222,49 @@ func (conn *ExecerContext) execContext(ctx context.Context, query string, args [
 }
 
 func (conn *ExecerContext) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	for attempt := 1; ; attempt++ {
+		results, delay, err := conn.exec(ctx, query, args, attempt)
+		if delay == 0 || !sleep(ctx, delay) {
+			return results, err
+		}
+	}
+}
+
+// exec runs an attempt of ExecContext, delay is how long to wait before
+// the next one, or 0 if it must not be retried.
+func (conn *ExecerContext) exec(ctx context.Context, query string, args []driver.NamedValue, attempt int) (_ driver.Result, delay time.Duration, _ error) {
 	var err error
 
 	list := namedToInterface(args)
+	ctx = withCall(ctx, conn.Conn, OpExec, args)
+	callFromContext(ctx).attempt = attempt
 
 	// Exec `Before` Hooks
 	if ctx, err = conn.hooks.Before(ctx, query, list...); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
+	started := time.Now()
 	results, err := conn.execContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		// database/sql will fall back to a prepared statement, which is
+		// instrumented on its own.
+		handlerSkip(ctx, conn.hooks, query, list...)
+		return nil, 0, err
+	}
 	if err != nil {
-		return results, handlerErr(ctx, conn.hooks, err, query, list...)
+		elapsed := time.Since(started)
+		delay = conn.retryDelay(ctx, err, attempt)
+		err = handlerErr(ctx, conn.hooks, err, delay > 0, query, list...)
+		return results, delay, conn.wrapErr(err, OpExec, query, len(args), elapsed)
 	}
 
+	setResult(ctx, results)
 	if _, err := conn.hooks.After(ctx, query, list...); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return results, err
+	return results, 0, err
 }
 
 func (conn *ExecerContext) Exec(query string, args []driver.Value) (driver.Result, error) {
@@ -201,25 +308,52 @@ func (conn *QueryerContext) queryContext(ctx context.Context, query string, args
 }
 
 func (conn *QueryerContext) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	for attempt := 1; ; attempt++ {
+		rows, delay, err := conn.query(ctx, query, args, attempt)
+		if delay == 0 || !sleep(ctx, delay) {
+			return rows, err
+		}
+	}
+}
+
+// query runs an attempt of QueryContext, delay is how long to wait before
+// the next one, or 0 if it must not be retried.
+func (conn *QueryerContext) query(ctx context.Context, query string, args []driver.NamedValue, attempt int) (_ driver.Rows, delay time.Duration, _ error) {
 	var err error
 
 	list := namedToInterface(args)
+	ctx = withCall(ctx, conn.Conn, OpQuery, args)
+	callFromContext(ctx).attempt = attempt
 
 	// Query `Before` Hooks
 	if ctx, err = conn.hooks.Before(ctx, query, list...); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
+	started := time.Now()
 	results, err := conn.queryContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		// database/sql will fall back to a prepared statement, which is
+		// instrumented on its own.
+		handlerSkip(ctx, conn.hooks, query, list...)
+		return nil, 0, err
+	}
 	if err != nil {
-		return results, handlerErr(ctx, conn.hooks, err, query, list...)
+		elapsed := time.Since(started)
+		delay = conn.retryDelay(ctx, err, attempt)
+		err = handlerErr(ctx, conn.hooks, err, delay > 0, query, list...)
+		return results, delay, conn.wrapErr(err, OpQuery, query, len(args), elapsed)
 	}
 
-	if _, err := conn.hooks.After(ctx, query, list...); err != nil {
-		return nil, err
+	rowsCtx, err := conn.hooks.After(ctx, query, list...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if rowsCtx == nil {
+		rowsCtx = ctx
 	}
 
-	return results, err
+	return wrapRows(rowsCtx, conn.hooks, query, results), 0, err
 }
 
 // ExecerQueryerContext implements database/sql.driver.ExecerContext and
@@ -248,6 +382,14 @@ type Stmt struct {
 	Stmt  driver.Stmt
 	hooks Hooks
 	query string
+	conn  *Conn
+}
+
+func (stmt *Stmt) wrapErr(err error, op Op, nargs int, elapsed time.Duration) error {
+	if stmt.conn == nil {
+		return err
+	}
+	return stmt.conn.wrapErr(err, op, stmt.query, nargs, elapsed)
 }
 
 func (stmt *Stmt) execContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
@@ -267,17 +409,22 @@ func (stmt *Stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (dr
 	var err error
 
 	list := namedToInterface(args)
+	ctx = withCall(ctx, stmt.conn, OpStmtExec, args)
 
 	// Exec `Before` Hooks
 	if ctx, err = stmt.hooks.Before(ctx, stmt.query, list...); err != nil {
 		return nil, err
 	}
 
+	started := time.Now()
 	results, err := stmt.execContext(ctx, args)
 	if err != nil {
-		return results, handlerErr(ctx, stmt.hooks, err, stmt.query, list...)
+		elapsed := time.Since(started)
+		err = handlerErr(ctx, stmt.hooks, err, false, stmt.query, list...)
+		return results, stmt.wrapErr(err, OpStmtExec, len(args), elapsed)
 	}
 
+	setResult(ctx, results)
 	if _, err := stmt.hooks.After(ctx, stmt.query, list...); err != nil {
 		return nil, err
 	}
@@ -301,22 +448,30 @@ func (stmt *Stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (d
 	var err error
 
 	list := namedToInterface(args)
+	ctx = withCall(ctx, stmt.conn, OpStmtQuery, args)
 
 	// Exec Before Hooks
 	if ctx, err = stmt.hooks.Before(ctx, stmt.query, list...); err != nil {
 		return nil, err
 	}
 
+	started := time.Now()
 	rows, err := stmt.queryContext(ctx, args)
 	if err != nil {
-		return rows, handlerErr(ctx, stmt.hooks, err, stmt.query, list...)
+		elapsed := time.Since(started)
+		err = handlerErr(ctx, stmt.hooks, err, false, stmt.query, list...)
+		return rows, stmt.wrapErr(err, OpStmtQuery, len(args), elapsed)
 	}
 
-	if _, err := stmt.hooks.After(ctx, stmt.query, list...); err != nil {
+	rowsCtx, err := stmt.hooks.After(ctx, stmt.query, list...)
+	if err != nil {
 		return nil, err
 	}
+	if rowsCtx == nil {
+		rowsCtx = ctx
+	}
 
-	return rows, err
+	return wrapRows(rowsCtx, stmt.hooks, stmt.query, rows), err
 }
 
 func (stmt *Stmt) Close() error                                    { return stmt.Stmt.Close() }
@@ -326,8 +481,13 @@ func (stmt *Stmt) Query(args []driver.Value) (driver.Rows, error)  { return stmt
 
 // Wrap is used to create a new instrumented driver, it takes a vendor specific driver, and a Hooks instance to produce a new driver instance.
 // It's usually used inside a sql.Register() statement
-func Wrap(driver driver.Driver, hooks Hooks) driver.Driver {
-	return &Driver{driver, hooks}
+// Options may be given to change the behaviour of the instrumented driver.
+func Wrap(driver driver.Driver, hooks Hooks, opts ...Option) driver.Driver {
+	drv := &Driver{Driver: driver, hooks: hooks}
+	for _, opt := range opts {
+		opt(&drv.opts)
+	}
+	return drv
 }
 
 func namedToInterface(args []driver.NamedValue) []interface{} {
@@ -0,0 +1,103 @@
+package sqlhooks_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"testing"
+
+	"github.com/qustavo/sqlhooks/v2"
+	"github.com/qustavo/sqlhooks/v2/internal/skipdriver"
+	"github.com/qustavo/sqlhooks/v2/internal/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHooks struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (h *recordingHooks) record(call string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, call)
+}
+
+func (h *recordingHooks) Before(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	h.record("before")
+	return ctx, nil
+}
+
+func (h *recordingHooks) After(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+	h.record("after")
+	return ctx, nil
+}
+
+func (h *recordingHooks) OnError(ctx context.Context, err error, query string, args ...interface{}) error {
+	switch {
+	case sqlhooks.WillRetry(ctx):
+		h.record("error (retry)")
+	case sqlhooks.BadConn(ctx):
+		h.record("error (bad conn)")
+	default:
+		h.record("error")
+	}
+	return err
+}
+
+func (h *recordingHooks) OnSkip(ctx context.Context, query string, args ...interface{}) {
+	h.record("skip")
+}
+
+func TestErrSkip(t *testing.T) {
+	for _, it := range []struct {
+		name string
+		run  func(db *sql.DB) error
+	}{
+		{"Exec", func(db *sql.DB) error {
+			_, err := db.Exec("INSERT INTO t VALUES(?)", 1)
+			return err
+		}},
+		{"Query", func(db *sql.DB) error {
+			rows, err := db.Query("SELECT n FROM t WHERE n = ?", 1)
+			if err != nil {
+				return err
+			}
+			return rows.Close()
+		}},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			hooks := &recordingHooks{}
+			db := sqltest.OpenDriver(t, &skipdriver.Driver{}, sqlhooks.Compose(hooks), sqlhooks.WithQueryError(nil))
+
+			require.NoError(t, it.run(db))
+			assert.Equal(t, []string{"before", "skip", "before", "after"}, hooks.calls)
+		})
+	}
+}
+
+func TestErrBadConn(t *testing.T) {
+	hooks := &recordingHooks{}
+	db := sqltest.OpenDriver(t, &skipdriver.Driver{BadConns: 1}, hooks, sqlhooks.WithQueryError(nil))
+
+	_, err := db.Exec("INSERT INTO t VALUES(?)", 1)
+	require.NoError(t, err)
+	// the statement is skipped on the next connection, then prepared
+	assert.Equal(t, []string{"before", "error (bad conn)", "before", "skip", "before", "after"}, hooks.calls)
+}
+
+func TestErrBadConnNotWrapped(t *testing.T) {
+	hooks := &recordingHooks{}
+	db := sqltest.OpenDriver(t, &skipdriver.Driver{BadConns: 1}, hooks, sqlhooks.WithQueryError(nil))
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Conn doesn't retry on another connection, so ErrBadConn reaches the
+	// caller.
+	_, err = conn.ExecContext(context.Background(), "INSERT INTO t VALUES(?)", 1)
+	assert.Equal(t, driver.ErrBadConn, err)
+	assert.Equal(t, []string{"before", "error (bad conn)"}, hooks.calls)
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -49,6 +50,30 @@ func (h *testHooks) OnError(ctx context.Context, err error, query string, args .
 	return h.onError(ctx, err, query, args...)
 }
 
+// openDB returns an in-memory SQLite database instrumented by hooks, which is
+// closed when t ends, like internal/sqltest.Open, which this package can't
+// import.
+func openDB(t testing.TB, hooks Hooks, opts ...Option) *sql.DB {
+	return openDSN(t, ":memory:", hooks, opts...)
+}
+
+// openDSN is like openDB, opening the SQLite database named by dsn instead.
+func openDSN(t testing.TB, dsn string, hooks Hooks, opts ...Option) *sql.DB {
+	db := sql.OpenDB(connector{Wrap(&sqlite3.SQLiteDriver{}, hooks, opts...), dsn})
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// connector opens a *sql.DB without registering its driver.
+type connector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c connector) Driver() driver.Driver                        { return c.driver }
+
 type suite struct {
 	db    *sql.DB
 	hooks *testHooks
@@ -205,3 +230,43 @@ func TestNamedValueToValue(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, want, dargs)
 }
+
+func TestArgNames(t *testing.T) {
+	ctx := withCall(context.Background(), nil, OpQuery, []driver.NamedValue{
+		{Ordinal: 1, Value: "foo"},
+		{Ordinal: 2, Name: "id", Value: 42},
+	})
+	assert.Equal(t, []string{"", "id"}, ArgNames(ctx))
+	assert.Equal(t, OpQuery, OpFromContext(ctx))
+
+	ctx = withCall(context.Background(), nil, OpExec, []driver.NamedValue{{Ordinal: 1, Value: "foo"}})
+	assert.Nil(t, ArgNames(ctx))
+	assert.Equal(t, OpExec, OpFromContext(ctx))
+}
+
+func TestResultFromContext(t *testing.T) {
+	hooks := newTestHooks()
+	var affected []int64
+	hooks.after = func(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+		if result, ok := ResultFromContext(ctx); ok {
+			n, err := result.RowsAffected()
+			require.NoError(t, err)
+			affected = append(affected, n)
+		} else {
+			affected = append(affected, -1)
+		}
+		return ctx, nil
+	}
+
+	db := openDB(t, hooks)
+
+	_, err := db.Exec("CREATE TABLE t(id int)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO t VALUES (1), (2)")
+	require.NoError(t, err)
+	rows, err := db.Query("SELECT * FROM t")
+	require.NoError(t, err)
+	rows.Close()
+
+	assert.Equal(t, []int64{0, 2, -1}, affected)
+}
@@ -0,0 +1,88 @@
+package sqlhooks
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// TxEnd tells how a transaction ended.
+type TxEnd int
+
+const (
+	// TxCommit means the transaction was committed.
+	TxCommit TxEnd = iota + 1
+	// TxRollback means the transaction was rolled back.
+	TxRollback
+	// TxBeginFailed means the driver failed to start the transaction.
+	TxBeginFailed
+)
+
+func (e TxEnd) String() string {
+	switch e {
+	case TxCommit:
+		return "commit"
+	case TxRollback:
+		return "rollback"
+	case TxBeginFailed:
+		return "begin failed"
+	}
+	return "unknown"
+}
+
+// TxHooks instances will be called when transactions begin and end.
+// The context returned by BeginTx is passed to EndTx, and to the hooks of the
+// statements run within the transaction through TxFromContext. The context
+// given to BeginTx has the Instance of the driver, see InstanceFromContext.
+// If BeginTx returns an error the transaction is not started, and EndTx is
+// not called. err is the error returned by the driver, and the error
+// returned by EndTx is returned to the caller.
+type TxHooks interface {
+	BeginTx(ctx context.Context, opts driver.TxOptions) (context.Context, error)
+	EndTx(ctx context.Context, end TxEnd, err error) error
+}
+
+func (conn *Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	h, ok := conn.hooks.(TxHooks)
+	if ok {
+		var err error
+		ctx = withCall(ctx, conn, "", nil)
+		if ctx, err = h.BeginTx(ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := conn.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+	if err != nil {
+		if ok {
+			return nil, h.EndTx(ctx, TxBeginFailed, err)
+		}
+		return nil, err
+	}
+
+	conn.tx = ctx
+	return &Tx{Tx: tx, conn: conn, ctx: ctx}, nil
+}
+
+// Tx implements a database/sql/driver.Tx
+type Tx struct {
+	Tx   driver.Tx
+	conn *Conn
+	ctx  context.Context
+}
+
+func (tx *Tx) Commit() error {
+	return tx.end(TxCommit, tx.Tx.Commit())
+}
+
+func (tx *Tx) Rollback() error {
+	return tx.end(TxRollback, tx.Tx.Rollback())
+}
+
+func (tx *Tx) end(end TxEnd, err error) error {
+	tx.conn.tx = nil
+
+	if h, ok := tx.conn.hooks.(TxHooks); ok {
+		return h.EndTx(tx.ctx, end, err)
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package sqlhooks
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type txKey struct{}
+
+type txHooks struct {
+	*testHooks
+	begin func(ctx context.Context, opts driver.TxOptions) (context.Context, error)
+	ends  []TxEnd
+}
+
+func (h *txHooks) BeginTx(ctx context.Context, opts driver.TxOptions) (context.Context, error) {
+	if h.begin != nil {
+		return h.begin(ctx, opts)
+	}
+	return context.WithValue(ctx, txKey{}, len(h.ends)), nil
+}
+
+func (h *txHooks) EndTx(ctx context.Context, end TxEnd, err error) error {
+	h.ends = append(h.ends, end)
+	return err
+}
+
+func TestTxHooks(t *testing.T) {
+	hooks := &txHooks{testHooks: newTestHooks()}
+	var txs []interface{}
+	hooks.before = func(ctx context.Context, query string, args ...interface{}) (context.Context, error) {
+		if tx, ok := TxFromContext(ctx); ok {
+			txs = append(txs, tx.Value(txKey{}))
+		} else {
+			txs = append(txs, nil)
+		}
+		return ctx, nil
+	}
+
+	db := openDB(t, hooks)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("SELECT 1")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	_, err = db.Exec("SELECT 1")
+	require.NoError(t, err)
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Query("SELECT 1")
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	assert.Equal(t, []interface{}{0, nil, 1}, txs)
+	assert.Equal(t, []TxEnd{TxCommit, TxRollback}, hooks.ends)
+}
+
+func TestTxHooksInstance(t *testing.T) {
+	var instances []string
+	hooks := &txHooks{testHooks: newTestHooks()}
+	hooks.begin = func(ctx context.Context, opts driver.TxOptions) (context.Context, error) {
+		instance, _ := InstanceFromContext(ctx)
+		instances = append(instances, instance.Name)
+		return ctx, nil
+	}
+
+	db := openDB(t, hooks, WithInstance("primary", nil))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	assert.Equal(t, []string{"primary"}, instances)
+}
+
+func TestTxHooksBeginError(t *testing.T) {
+	hookErr := errors.New("not now")
+	hooks := &txHooks{testHooks: newTestHooks()}
+	hooks.begin = func(ctx context.Context, opts driver.TxOptions) (context.Context, error) {
+		return ctx, hookErr
+	}
+
+	db := openDB(t, hooks)
+
+	_, err := db.Begin()
+	assert.Equal(t, hookErr, err)
+	assert.Empty(t, hooks.ends)
+}
+
+func TestComposeTxHooks(t *testing.T) {
+	h1 := &txHooks{testHooks: newTestHooks()}
+	h2 := &txHooks{testHooks: newTestHooks()}
+	h2.begin = func(ctx context.Context, opts driver.TxOptions) (context.Context, error) {
+		assert.Equal(t, 0, ctx.Value(txKey{}), "context must be chained")
+		return ctx, nil
+	}
+	hooks := Compose(h1, newTestHooks(), h2).(TxHooks)
+
+	ctx, err := hooks.BeginTx(context.Background(), driver.TxOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, ctx.Value(txKey{}))
+
+	cause := errors.New("commit failed")
+	assert.Equal(t, cause, hooks.EndTx(ctx, TxCommit, cause))
+	assert.Equal(t, []TxEnd{TxCommit}, h1.ends)
+	assert.Equal(t, []TxEnd{TxCommit}, h2.ends)
+}